@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	cadenceImportPath = "github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	clientImportPath  = "go.uber.org/cadence/client"
+	gomockImportPath  = "github.com/golang/mock/gomock"
+)
+
+// generateCode generates the registration code, typed clients/stubs and name constants
+func generateCode(src *sourceFile) ([]byte, error) {
+	imports := map[string]string{"cadence": cadenceImportPath}
+	if len(src.Workflows) > 0 {
+		imports["context"] = contextImportPath
+		imports["client"] = clientImportPath
+	}
+	if len(src.Activities) > 0 {
+		imports["workflow"] = cadenceWorkflowImportPath
+	}
+	var typeStrings []string
+	for _, wi := range src.Workflows {
+		for _, m := range wi.Methods {
+			typeStrings = append(typeStrings, m.In, m.Out)
+		}
+		for _, nt := range append(append([]namedType{}, wi.Signals...), wi.Queries...) {
+			typeStrings = append(typeStrings, nt.Type)
+		}
+	}
+	for _, ai := range src.Activities {
+		for _, m := range ai.Methods {
+			typeStrings = append(typeStrings, m.In, m.Out)
+		}
+	}
+	if err := src.addUsedImports(imports, typeStrings); err != nil {
+		return nil, err
+	}
+	return render(codeTemplate, src, imports)
+}
+
+// generateMocks generates gomock compatible mocks for the annotated interfaces and generated clients/stubs
+func generateMocks(src *sourceFile) ([]byte, error) {
+	imports := map[string]string{"gomock": gomockImportPath, "reflect": "reflect"}
+	if len(src.Workflows) > 0 {
+		imports["cadence"] = cadenceImportPath
+		imports["context"] = contextImportPath
+		imports["client"] = clientImportPath
+	}
+	if len(src.Activities) > 0 {
+		imports["cadence"] = cadenceImportPath
+		imports["workflow"] = cadenceWorkflowImportPath
+	}
+	var typeStrings []string
+	for _, wi := range src.Workflows {
+		for _, m := range wi.AllMethods {
+			typeStrings = append(typeStrings, m.Params...)
+			typeStrings = append(typeStrings, m.Results...)
+		}
+		for _, nt := range append(append([]namedType{}, wi.Signals...), wi.Queries...) {
+			typeStrings = append(typeStrings, nt.Type)
+		}
+	}
+	for _, ai := range src.Activities {
+		for _, m := range ai.AllMethods {
+			typeStrings = append(typeStrings, m.Params...)
+			typeStrings = append(typeStrings, m.Results...)
+		}
+	}
+	if err := src.addUsedImports(imports, typeStrings); err != nil {
+		return nil, err
+	}
+	return render(mockTemplate, src, imports)
+}
+
+// addUsedImports adds the imports of the source file which are used by the given type expressions
+func (s *sourceFile) addUsedImports(imports map[string]string, typeStrings []string) error {
+	for _, ts := range typeStrings {
+		expr, err := parser.ParseExpr(ts)
+		if err != nil {
+			return errors.Wrap(err, "bad type %s", ts)
+		}
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok {
+					for _, imp := range s.Imports {
+						if imp.Name == x.Name {
+							imports[imp.Name] = imp.Path
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return nil
+}
+
+type templateData struct {
+	*sourceFile
+	ImportLines []string
+}
+
+func render(tmpl *template.Template, src *sourceFile, imports map[string]string) ([]byte, error) {
+	lines := make([]string, 0, len(imports))
+	for name, path := range imports {
+		if name == path[strings.LastIndex(path, "/")+1:] {
+			lines = append(lines, `"`+path+`"`)
+		} else {
+			lines = append(lines, name+` "`+path+`"`)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return importPath(lines[i]) < importPath(lines[j])
+	})
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, templateData{sourceFile: src, ImportLines: lines}); err != nil {
+		return nil, errors.Wrap(err, "failed to generate code")
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to format generated code:\n%s", buf.String())
+	}
+	return out, nil
+}
+
+// importPath returns the path from an import line e.g. `alias "a/b"` returns a/b
+func importPath(line string) string {
+	return strings.Trim(line[strings.Index(line, `"`):], `"`)
+}
+
+// mockData is the input for the "mock" template - one mock type
+type mockData struct {
+	Name    string
+	Methods []*mockMethod
+}
+
+var funcs = template.FuncMap{
+	"lowerFirst": func(s string) string {
+		if len(s) == 0 {
+			return s
+		}
+		return strings.ToLower(s[:1]) + s[1:]
+	},
+	"args": func(params []string) string {
+		out := make([]string, 0, len(params))
+		for i, p := range params {
+			out = append(out, "arg"+strconv.Itoa(i)+" "+p)
+		}
+		return strings.Join(out, ", ")
+	},
+	"argNames": func(params []string) string {
+		out := make([]string, 0, len(params))
+		for i := range params {
+			out = append(out, "arg"+strconv.Itoa(i))
+		}
+		return strings.Join(out, ", ")
+	},
+	"argInterfaces": func(params []string) string {
+		out := make([]string, 0, len(params))
+		for i := range params {
+			out = append(out, "arg"+strconv.Itoa(i))
+		}
+		if len(out) == 0 {
+			return ""
+		}
+		return strings.Join(out, ", ") + " interface{}"
+	},
+	"results": func(results []string) string {
+		if len(results) <= 1 {
+			return strings.Join(results, "")
+		}
+		return "(" + strings.Join(results, ", ") + ")"
+	},
+	"retNames": func(results []string) string {
+		out := make([]string, 0, len(results))
+		for i := range results {
+			out = append(out, "ret"+strconv.Itoa(i))
+		}
+		return strings.Join(out, ", ")
+	},
+	"comma": func(params []string) string {
+		if len(params) == 0 {
+			return ""
+		}
+		return ", "
+	},
+	"dict": func(name string, methods []*mockMethod) mockData {
+		return mockData{Name: name, Methods: methods}
+	},
+	"clientMethods": func(wi *workflowInterface) []*mockMethod {
+		var out []*mockMethod
+		for _, m := range wi.Methods {
+			out = append(out,
+				&mockMethod{Name: "Start" + m.Name, Params: []string{"context.Context", "client.StartWorkflowOptions", m.In}, Results: []string{"*cadence.WorkflowHandle[" + m.Out + "]", "error"}},
+				&mockMethod{Name: "Execute" + m.Name, Params: []string{"context.Context", "client.StartWorkflowOptions", m.In}, Results: []string{m.Out, "error"}},
+			)
+		}
+		for _, q := range wi.Queries {
+			out = append(out, &mockMethod{Name: "Query" + q.Name, Params: []string{"context.Context", "cadence.ExecutionRef"}, Results: []string{q.Type, "error"}})
+		}
+		for _, s := range wi.Signals {
+			out = append(out, &mockMethod{Name: "Signal" + s.Name, Params: []string{"context.Context", "cadence.ExecutionRef", s.Type}, Results: []string{"error"}})
+		}
+		return out
+	},
+	"stubMethods": func(ai *activityInterface) []*mockMethod {
+		var out []*mockMethod
+		for _, m := range ai.Methods {
+			out = append(out,
+				&mockMethod{Name: m.Name, Params: []string{"workflow.Context", m.In}, Results: []string{m.Out, "error"}},
+				&mockMethod{Name: m.Name + "Async", Params: []string{"workflow.Context", m.In}, Results: []string{"cadence.Future[" + m.Out + "]"}},
+			)
+		}
+		return out
+	},
+}
+
+var codeTemplate = template.Must(template.New("code").Funcs(funcs).Parse(`// Code generated by gox-workflow-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .ImportLines}}
+	{{.}}
+{{- end}}
+)
+{{range $wi := .Workflows}}
+// Workflow, query and signal names of {{$wi.Name}}
+const (
+{{- range $wi.Methods}}
+	{{$wi.Name}}{{.Name}}WorkflowName = "{{$.Package}}.{{$wi.Name}}.{{.Name}}"
+{{- end}}
+{{- range $wi.Queries}}
+	{{$wi.Name}}{{.Name}}QueryName = "{{.Name}}"
+{{- end}}
+{{- range $wi.Signals}}
+	{{$wi.Name}}{{.Name}}SignalName = "{{.Name}}"
+{{- end}}
+)
+{{if or $wi.Queries $wi.Signals}}
+// Typed queries and signals of {{$wi.Name}}
+var (
+{{- range $wi.Queries}}
+	{{$wi.Name}}{{.Name}}Query = cadence.NewQueryDef[{{.Type}}]({{$wi.Name}}{{.Name}}QueryName)
+{{- end}}
+{{- range $wi.Signals}}
+	{{$wi.Name}}{{.Name}}Signal = cadence.NewSignalDef[{{.Type}}]({{$wi.Name}}{{.Name}}SignalName)
+{{- end}}
+)
+{{end}}
+// Register{{$wi.Name}} registers all workflows of {{$wi.Name}} with cadence
+func Register{{$wi.Name}}(impl {{$wi.Name}}) {
+{{- range $wi.Methods}}
+	cadence.NewWorkflowDefWithName({{$wi.Name}}{{.Name}}WorkflowName, impl.{{.Name}}).Register()
+{{- end}}
+}
+
+// {{$wi.Name}}Client is a typed client for the workflows of {{$wi.Name}}
+type {{$wi.Name}}Client interface {
+{{- range $wi.Methods}}
+	Start{{.Name}}(ctx context.Context, options client.StartWorkflowOptions, in {{.In}}) (*cadence.WorkflowHandle[{{.Out}}], error)
+	Execute{{.Name}}(ctx context.Context, options client.StartWorkflowOptions, in {{.In}}) ({{.Out}}, error)
+{{- end}}
+{{- range $wi.Queries}}
+	Query{{.Name}}(ctx context.Context, ref cadence.ExecutionRef) ({{.Type}}, error)
+{{- end}}
+{{- range $wi.Signals}}
+	Signal{{.Name}}(ctx context.Context, ref cadence.ExecutionRef, arg {{.Type}}) error
+{{- end}}
+}
+
+// New{{$wi.Name}}Client creates a typed client for the workflows of {{$wi.Name}}
+func New{{$wi.Name}}Client(api cadence.Api) {{$wi.Name}}Client {
+	return &{{lowerFirst $wi.Name}}Client{api: api}
+}
+
+type {{lowerFirst $wi.Name}}Client struct {
+	api cadence.Api
+}
+{{range $wi.Methods}}
+func (c *{{lowerFirst $wi.Name}}Client) Start{{.Name}}(ctx context.Context, options client.StartWorkflowOptions, in {{.In}}) (*cadence.WorkflowHandle[{{.Out}}], error) {
+	return cadence.NewWorkflowDefWithName[{{.In}}, {{.Out}}]({{$wi.Name}}{{.Name}}WorkflowName, nil).Start(ctx, c.api, options, in)
+}
+
+func (c *{{lowerFirst $wi.Name}}Client) Execute{{.Name}}(ctx context.Context, options client.StartWorkflowOptions, in {{.In}}) ({{.Out}}, error) {
+	return cadence.NewWorkflowDefWithName[{{.In}}, {{.Out}}]({{$wi.Name}}{{.Name}}WorkflowName, nil).Execute(ctx, c.api, options, in)
+}
+{{end}}
+{{- range $wi.Queries}}
+func (c *{{lowerFirst $wi.Name}}Client) Query{{.Name}}(ctx context.Context, ref cadence.ExecutionRef) ({{.Type}}, error) {
+	return {{$wi.Name}}{{.Name}}Query.Query(ctx, c.api, ref)
+}
+{{end}}
+{{- range $wi.Signals}}
+func (c *{{lowerFirst $wi.Name}}Client) Signal{{.Name}}(ctx context.Context, ref cadence.ExecutionRef, arg {{.Type}}) error {
+	return {{$wi.Name}}{{.Name}}Signal.Send(ctx, c.api, ref, arg)
+}
+{{end}}
+{{- end}}
+{{- range $ai := .Activities}}
+// Activity names of {{$ai.Name}}
+const (
+{{- range $ai.Methods}}
+	{{$ai.Name}}{{.Name}}ActivityName = "{{$.Package}}.{{$ai.Name}}.{{.Name}}"
+{{- end}}
+)
+
+// Register{{$ai.Name}} registers all activities of {{$ai.Name}} with cadence
+func Register{{$ai.Name}}(impl {{$ai.Name}}) {
+{{- range $ai.Methods}}
+	cadence.NewActivityDefWithName({{$ai.Name}}{{.Name}}ActivityName, impl.{{.Name}}).Register()
+{{- end}}
+}
+
+// {{$ai.Name}}Stub executes the activities of {{$ai.Name}} from a workflow
+type {{$ai.Name}}Stub interface {
+{{- range $ai.Methods}}
+	{{.Name}}(ctx workflow.Context, in {{.In}}) ({{.Out}}, error)
+	{{.Name}}Async(ctx workflow.Context, in {{.In}}) cadence.Future[{{.Out}}]
+{{- end}}
+}
+
+// New{{$ai.Name}}Stub creates a stub to execute the activities of {{$ai.Name}} from a workflow
+func New{{$ai.Name}}Stub() {{$ai.Name}}Stub {
+	return &{{lowerFirst $ai.Name}}Stub{}
+}
+
+type {{lowerFirst $ai.Name}}Stub struct {
+}
+{{range $ai.Methods}}
+func (s *{{lowerFirst $ai.Name}}Stub) {{.Name}}(ctx workflow.Context, in {{.In}}) ({{.Out}}, error) {
+	return cadence.NewActivityDefWithName[{{.In}}, {{.Out}}]({{$ai.Name}}{{.Name}}ActivityName, nil).Execute(ctx, in)
+}
+
+func (s *{{lowerFirst $ai.Name}}Stub) {{.Name}}Async(ctx workflow.Context, in {{.In}}) cadence.Future[{{.Out}}] {
+	return cadence.NewActivityDefWithName[{{.In}}, {{.Out}}]({{$ai.Name}}{{.Name}}ActivityName, nil).ExecuteAsync(ctx, in)
+}
+{{end}}
+{{- end}}
+`))
+
+var mockTemplate = template.Must(template.New("mocks").Funcs(funcs).Parse(`// Code generated by gox-workflow-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .ImportLines}}
+	{{.}}
+{{- end}}
+)
+{{range $wi := .Workflows}}
+{{- template "mock" dict $wi.Name $wi.AllMethods}}
+{{- template "mock" dict (print $wi.Name "Client") (clientMethods $wi)}}
+{{- end}}
+{{- range $ai := .Activities}}
+{{- template "mock" dict $ai.Name $ai.AllMethods}}
+{{- template "mock" dict (print $ai.Name "Stub") (stubMethods $ai)}}
+{{- end}}
+
+{{- define "mock"}}
+// Mock{{.Name}} is a mock of {{.Name}} interface.
+type Mock{{.Name}} struct {
+	ctrl     *gomock.Controller
+	recorder *Mock{{.Name}}MockRecorder
+}
+
+// Mock{{.Name}}MockRecorder is the mock recorder for Mock{{.Name}}.
+type Mock{{.Name}}MockRecorder struct {
+	mock *Mock{{.Name}}
+}
+
+// NewMock{{.Name}} creates a new mock instance.
+func NewMock{{.Name}}(ctrl *gomock.Controller) *Mock{{.Name}} {
+	mock := &Mock{{.Name}}{ctrl: ctrl}
+	mock.recorder = &Mock{{.Name}}MockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mock{{.Name}}) EXPECT() *Mock{{.Name}}MockRecorder {
+	return m.recorder
+}
+{{range $m := .Methods}}
+// {{$m.Name}} mocks base method.
+func (m *Mock{{$.Name}}) {{$m.Name}}({{args $m.Params}}) {{results $m.Results}} {
+	m.ctrl.T.Helper()
+	{{if $m.Results}}ret := {{end}}m.ctrl.Call(m, "{{$m.Name}}"{{comma $m.Params}}{{argNames $m.Params}})
+{{- range $i, $r := $m.Results}}
+	ret{{$i}}, _ := ret[{{$i}}].({{$r}})
+{{- end}}
+{{- if $m.Results}}
+	return {{retNames $m.Results}}
+{{- end}}
+}
+
+// {{$m.Name}} indicates an expected call of {{$m.Name}}.
+func (mr *Mock{{$.Name}}MockRecorder) {{$m.Name}}({{argInterfaces $m.Params}}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "{{$m.Name}}", reflect.TypeOf((*Mock{{$.Name}})(nil).{{$m.Name}}){{comma $m.Params}}{{argNames $m.Params}})
+}
+{{end}}
+{{- end}}
+`))
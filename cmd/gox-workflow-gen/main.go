@@ -0,0 +1,89 @@
+// gox-workflow-gen generates typed cadence clients from Go interfaces annotated as workflows or activities.
+//
+// Annotate the interfaces in a Go file:
+//
+//	// gox:workflow
+//	// gox:query Status *OrderStatus
+//	// gox:signal Approve Approval
+//	type OrderWorkflows interface {
+//		CreateOrder(ctx workflow.Context, in Order) (*Receipt, error)
+//	}
+//
+//	// gox:activity
+//	type PaymentActivities interface {
+//		Charge(ctx context.Context, in Order) (*Payment, error)
+//	}
+//
+// and add the go:generate directive to the same file:
+//
+//	//go:generate go run github.com/devlibx/gox-workfkow/cmd/gox-workflow-gen -source=$GOFILE
+//
+// For each workflow interface it generates name constants, Register<Name>(impl), a typed <Name>Client and typed
+// query/signal definitions. For each activity interface it generates name constants, Register<Name>(impl) and a
+// <Name>Stub to call the activities from a workflow. gomock compatible mocks are generated for all the annotated
+// interfaces and generated clients/stubs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	source := flag.String("source", os.Getenv("GOFILE"), "Go file with annotated interfaces (default $GOFILE)")
+	output := flag.String("output", "", "output file for the generated code (default <source>_gox_workflow.go)")
+	mockOutput := flag.String("mock_output", "", "output file for the generated mocks (default <source>_gox_workflow_mock.go)")
+	noMocks := flag.Bool("no_mocks", false, "do not generate mocks")
+	flag.Parse()
+
+	if len(*source) == 0 {
+		fmt.Fprintln(os.Stderr, "gox-workflow-gen: -source is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	base := strings.TrimSuffix(*source, filepath.Ext(*source))
+	if len(*output) == 0 {
+		*output = base + "_gox_workflow.go"
+	}
+	if len(*mockOutput) == 0 {
+		*mockOutput = base + "_gox_workflow_mock.go"
+	}
+
+	if err := run(*source, *output, *mockOutput, !*noMocks); err != nil {
+		fmt.Fprintf(os.Stderr, "gox-workflow-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(source string, output string, mockOutput string, mocks bool) error {
+	src, err := parseSource(source)
+	if err != nil {
+		return err
+	}
+	if len(src.Workflows) == 0 && len(src.Activities) == 0 {
+		return fmt.Errorf("no interface annotated with %s or %s found in %s", annotationWorkflow, annotationActivity, source)
+	}
+
+	code, err := generateCode(src)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(output, code, 0644); err != nil {
+		return err
+	}
+
+	if mocks {
+		code, err = generateMocks(src)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(mockOutput, code, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"github.com/devlibx/gox-base/v2/errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	cadenceWorkflowImportPath = "go.uber.org/cadence/workflow"
+	contextImportPath         = "context"
+
+	annotationWorkflow = "gox:workflow"
+	annotationActivity = "gox:activity"
+	annotationSignal   = "gox:signal"
+	annotationQuery    = "gox:query"
+)
+
+// sourceFile is the parsed form of a Go file with annotated interfaces
+type sourceFile struct {
+	Package    string
+	Imports    []importSpec
+	Workflows  []*workflowInterface
+	Activities []*activityInterface
+}
+
+type importSpec struct {
+	Name string // name used in the file (alias or last element of path)
+	Path string
+}
+
+// method is a workflow or activity method - func(ctx, in In) (Out, error)
+type method struct {
+	Name   string
+	In     string
+	Out    string
+	Params []string // all param types - used by mocks
+}
+
+type namedType struct {
+	Name string
+	Type string
+}
+
+type workflowInterface struct {
+	Name    string
+	Methods []*method
+	Signals []namedType
+	Queries []namedType
+
+	// all methods of the interface (used for mocks)
+	AllMethods []*mockMethod
+}
+
+type activityInterface struct {
+	Name       string
+	Methods    []*method
+	AllMethods []*mockMethod
+}
+
+// mockMethod is a method signature used to generate gomock mocks
+type mockMethod struct {
+	Name    string
+	Params  []string
+	Results []string
+}
+
+// parseSource parses the file and finds all interfaces annotated with "gox:workflow" or "gox:activity"
+func parseSource(path string) (*sourceFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse source file %s", path)
+	}
+
+	src := &sourceFile{Package: file.Name.Name}
+	var unnamed []string
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			src.Imports = append(src.Imports, importSpec{Name: imp.Name.Name, Path: p})
+		} else {
+			unnamed = append(unnamed, p)
+		}
+	}
+	names := packageNames(filepath.Dir(path), unnamed)
+	for _, p := range unnamed {
+		src.Imports = append(src.Imports, importSpec{Name: names[p], Path: p})
+	}
+
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			annotations := readAnnotations(doc)
+
+			switch {
+			case annotations.has(annotationWorkflow):
+				wi, err := src.parseWorkflowInterface(fset, ts.Name.Name, it, annotations)
+				if err != nil {
+					return nil, err
+				}
+				src.Workflows = append(src.Workflows, wi)
+			case annotations.has(annotationActivity):
+				ai, err := src.parseActivityInterface(fset, ts.Name.Name, it)
+				if err != nil {
+					return nil, err
+				}
+				src.Activities = append(src.Activities, ai)
+			}
+		}
+	}
+	return src, nil
+}
+
+func (s *sourceFile) parseWorkflowInterface(fset *token.FileSet, name string, it *ast.InterfaceType, annotations annotations) (*workflowInterface, error) {
+	wi := &workflowInterface{Name: name}
+	for _, value := range annotations.values(annotationSignal) {
+		nt, err := parseNamedType(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad %s annotation on %s", annotationSignal, name)
+		}
+		wi.Signals = append(wi.Signals, nt)
+	}
+	for _, value := range annotations.values(annotationQuery) {
+		nt, err := parseNamedType(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad %s annotation on %s", annotationQuery, name)
+		}
+		wi.Queries = append(wi.Queries, nt)
+	}
+
+	methods, all, err := s.parseMethods(fset, it, cadenceWorkflowImportPath, "Context")
+	if err != nil {
+		return nil, errors.Wrap(err, "bad workflow interface %s", name)
+	}
+	wi.Methods, wi.AllMethods = methods, all
+	return wi, nil
+}
+
+func (s *sourceFile) parseActivityInterface(fset *token.FileSet, name string, it *ast.InterfaceType) (*activityInterface, error) {
+	methods, all, err := s.parseMethods(fset, it, contextImportPath, "Context")
+	if err != nil {
+		return nil, errors.Wrap(err, "bad activity interface %s", name)
+	}
+	return &activityInterface{Name: name, Methods: methods, AllMethods: all}, nil
+}
+
+// parseMethods makes sure every method is func(ctx <ctxPkg>.<ctxName>, in In) (Out, error)
+func (s *sourceFile) parseMethods(fset *token.FileSet, it *ast.InterfaceType, ctxPkg string, ctxName string) ([]*method, []*mockMethod, error) {
+	var methods []*method
+	var all []*mockMethod
+	for _, field := range it.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, nil, errors.New("%s: embedded interfaces are not supported", fset.Position(field.Pos()))
+		}
+
+		params := flattenFields(ft.Params)
+		results := flattenFields(ft.Results)
+		mm := &mockMethod{Name: field.Names[0].Name}
+		for _, p := range params {
+			mm.Params = append(mm.Params, types.ExprString(p))
+		}
+		for _, r := range results {
+			mm.Results = append(mm.Results, types.ExprString(r))
+		}
+		all = append(all, mm)
+
+		if len(params) != 2 || !s.isType(params[0], ctxPkg, ctxName) || len(results) != 2 || types.ExprString(results[1]) != "error" {
+			return nil, nil, errors.New("%s: method %s must be func(ctx %s.%s, in In) (Out, error)", fset.Position(field.Pos()), mm.Name, ctxPkg, ctxName)
+		}
+		methods = append(methods, &method{Name: mm.Name, In: mm.Params[1], Out: mm.Results[0], Params: mm.Params})
+	}
+	return methods, all, nil
+}
+
+// packageNames finds the package names of the imports using "go list". The last element of the import path is used
+// if go list fails (e.g. package is not downloaded yet).
+func packageNames(dir string, paths []string) map[string]string {
+	names := map[string]string{}
+	for _, p := range paths {
+		names[p] = guessPackageName(p)
+	}
+	if len(paths) == 0 {
+		return names
+	}
+
+	cmd := exec.Command("go", append([]string{"list", "-e", "-f", "{{.ImportPath}} {{.Name}}"}, paths...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return names
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if p, name, ok := strings.Cut(strings.TrimSpace(line), " "); ok && len(name) > 0 {
+			names[p] = name
+		}
+	}
+	return names
+}
+
+// guessPackageName returns the last element of the import path ignoring the major version suffix
+func guessPackageName(path string) string {
+	elements := strings.Split(path, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elements[len(elements)-2]
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// isType returns true if the expression is <pkg>.<name> where pkg is imported with the given path
+func (s *sourceFile) isType(expr ast.Expr, pkgPath string, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, imp := range s.Imports {
+		if imp.Name == x.Name && imp.Path == pkgPath {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenFields expands "a, b int" to two fields
+func flattenFields(fl *ast.FieldList) []ast.Expr {
+	var out []ast.Expr
+	if fl == nil {
+		return out
+	}
+	for _, f := range fl.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, f.Type)
+		}
+	}
+	return out
+}
+
+// parseNamedType parses "<Name> <GoType>" e.g. "Status *OrderStatus"
+func parseNamedType(value string) (namedType, error) {
+	parts := strings.Fields(value)
+	if len(parts) < 2 {
+		return namedType{}, errors.New("expected '<name> <type>' but found '%s'", value)
+	}
+	typeStr := strings.Join(parts[1:], " ")
+	if _, err := parser.ParseExpr(typeStr); err != nil {
+		return namedType{}, errors.Wrap(err, "bad type '%s'", typeStr)
+	}
+	return namedType{Name: parts[0], Type: typeStr}, nil
+}
+
+// annotations are the "gox:xxx value" lines from a doc comment
+type annotations map[string][]string
+
+func readAnnotations(doc *ast.CommentGroup) annotations {
+	out := annotations{}
+	if doc == nil {
+		return out
+	}
+	for _, c := range doc.List {
+		line := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c.Text, "//"), "/*"))
+		if !strings.HasPrefix(line, "gox:") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		out[key] = append(out[key], strings.TrimSpace(value))
+	}
+	return out
+}
+
+func (a annotations) has(key string) bool {
+	_, ok := a[key]
+	return ok
+}
+
+func (a annotations) values(key string) []string {
+	return a[key]
+}
@@ -0,0 +1,22 @@
+// gox-workflow-vet reports non-deterministic code in cadence workflow functions.
+//
+// Run it directly:
+//
+//	go run github.com/devlibx/gox-workfkow/cmd/gox-workflow-vet ./...
+//
+// or as a vet tool:
+//
+//	go install github.com/devlibx/gox-workfkow/cmd/gox-workflow-vet
+//	go vet -vettool=$(which gox-workflow-vet) ./...
+//
+// See package determinism for the reported constructs and the allow list options.
+package main
+
+import (
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence/determinism"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(determinism.Analyzer)
+}
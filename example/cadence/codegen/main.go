@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/serialization"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"github.com/google/uuid"
+	"go.uber.org/cadence/client"
+	"os"
+	"time"
+)
+
+//go:embed config.yaml
+var config string
+
+func main() {
+
+	// Make sure to set the env variables
+	if os.Getenv("HOST") == "" || os.Getenv("TASK_LIST") == "" {
+		panic("HOST and TASK_LIST are mandatory - HOST=<your cadence host:port> TASK_LIST=<your task list>")
+	}
+
+	// Read config file into config object
+	config = os.ExpandEnv(config)
+	c := cadence.Config{}
+	err := serialization.ReadYamlFromString(config, &c)
+	if err != nil {
+		panic(err)
+	}
+
+	// Register workflows and activities using generated code
+	RegisterOrderWorkflows(&orderWorkflows{payments: NewPaymentActivitiesStub()})
+	RegisterPaymentActivities(&paymentActivities{})
+
+	workflowApi, err := cadence.NewCadenceClient(gox.NewCrossFunction(), &c)
+	if err != nil {
+		panic(err)
+	}
+	if err = workflowApi.Start(context.Background()); err != nil {
+		panic(err)
+	}
+
+	// Use the generated typed client
+	orders := NewOrderWorkflowsClient(workflowApi)
+	handle, err := orders.StartCreateOrder(context.Background(), client.StartWorkflowOptions{
+		ID:                              uuid.New().String(),
+		TaskList:                        "server_2_ts_1",
+		ExecutionStartToCloseTimeout:    10 * time.Minute,
+		DecisionTaskStartToCloseTimeout: 10 * time.Minute,
+	}, Order{ID: uuid.New().String(), Amount: 10})
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(2 * time.Second)
+	if status, err := orders.QueryStatus(context.Background(), handle.ExecutionRef); err == nil {
+		fmt.Println("status before approval:", status.Status)
+	}
+
+	if err = orders.SignalApprove(context.Background(), handle.ExecutionRef, Approval{Approved: true, By: "admin"}); err != nil {
+		panic(err)
+	}
+
+	receipt, err := handle.Get(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("final status:", receipt.Status)
+}
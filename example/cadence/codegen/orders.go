@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+//go:generate go run github.com/devlibx/gox-workfkow/cmd/gox-workflow-gen -source=$GOFILE
+
+type Order struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type Receipt struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+type Approval struct {
+	Approved bool   `json:"approved"`
+	By       string `json:"by"`
+}
+
+// OrderWorkflows are the workflows to process an order
+//
+// gox:workflow
+// gox:query Status *Receipt
+// gox:signal Approve Approval
+type OrderWorkflows interface {
+	CreateOrder(ctx workflow.Context, in Order) (*Receipt, error)
+}
+
+// PaymentActivities are the activities used by OrderWorkflows
+//
+// gox:activity
+type PaymentActivities interface {
+	Charge(ctx context.Context, in Order) (*Receipt, error)
+}
+
+type orderWorkflows struct {
+	payments PaymentActivitiesStub
+}
+
+func (o *orderWorkflows) CreateOrder(ctx workflow.Context, in Order) (*Receipt, error) {
+	receipt := &Receipt{OrderID: in.ID, Status: "WAITING_FOR_APPROVAL"}
+	if err := OrderWorkflowsStatusQuery.SetHandler(ctx, func() (*Receipt, error) { return receipt, nil }); err != nil {
+		return nil, err
+	}
+
+	if approval := OrderWorkflowsApproveSignal.Receive(ctx); !approval.Approved {
+		receipt.Status = "REJECTED"
+		return receipt, nil
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskList:               "server_2_ts_1",
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	return o.payments.Charge(ctx, in)
+}
+
+type paymentActivities struct {
+}
+
+func (p *paymentActivities) Charge(ctx context.Context, in Order) (*Receipt, error) {
+	return &Receipt{OrderID: in.ID, Status: "CHARGED"}, nil
+}
@@ -0,0 +1,93 @@
+// Code generated by gox-workflow-gen. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+)
+
+// Workflow, query and signal names of OrderWorkflows
+const (
+	OrderWorkflowsCreateOrderWorkflowName = "main.OrderWorkflows.CreateOrder"
+	OrderWorkflowsStatusQueryName         = "Status"
+	OrderWorkflowsApproveSignalName       = "Approve"
+)
+
+// Typed queries and signals of OrderWorkflows
+var (
+	OrderWorkflowsStatusQuery   = cadence.NewQueryDef[*Receipt](OrderWorkflowsStatusQueryName)
+	OrderWorkflowsApproveSignal = cadence.NewSignalDef[Approval](OrderWorkflowsApproveSignalName)
+)
+
+// RegisterOrderWorkflows registers all workflows of OrderWorkflows with cadence
+func RegisterOrderWorkflows(impl OrderWorkflows) {
+	cadence.NewWorkflowDefWithName(OrderWorkflowsCreateOrderWorkflowName, impl.CreateOrder).Register()
+}
+
+// OrderWorkflowsClient is a typed client for the workflows of OrderWorkflows
+type OrderWorkflowsClient interface {
+	StartCreateOrder(ctx context.Context, options client.StartWorkflowOptions, in Order) (*cadence.WorkflowHandle[*Receipt], error)
+	ExecuteCreateOrder(ctx context.Context, options client.StartWorkflowOptions, in Order) (*Receipt, error)
+	QueryStatus(ctx context.Context, ref cadence.ExecutionRef) (*Receipt, error)
+	SignalApprove(ctx context.Context, ref cadence.ExecutionRef, arg Approval) error
+}
+
+// NewOrderWorkflowsClient creates a typed client for the workflows of OrderWorkflows
+func NewOrderWorkflowsClient(api cadence.Api) OrderWorkflowsClient {
+	return &orderWorkflowsClient{api: api}
+}
+
+type orderWorkflowsClient struct {
+	api cadence.Api
+}
+
+func (c *orderWorkflowsClient) StartCreateOrder(ctx context.Context, options client.StartWorkflowOptions, in Order) (*cadence.WorkflowHandle[*Receipt], error) {
+	return cadence.NewWorkflowDefWithName[Order, *Receipt](OrderWorkflowsCreateOrderWorkflowName, nil).Start(ctx, c.api, options, in)
+}
+
+func (c *orderWorkflowsClient) ExecuteCreateOrder(ctx context.Context, options client.StartWorkflowOptions, in Order) (*Receipt, error) {
+	return cadence.NewWorkflowDefWithName[Order, *Receipt](OrderWorkflowsCreateOrderWorkflowName, nil).Execute(ctx, c.api, options, in)
+}
+
+func (c *orderWorkflowsClient) QueryStatus(ctx context.Context, ref cadence.ExecutionRef) (*Receipt, error) {
+	return OrderWorkflowsStatusQuery.Query(ctx, c.api, ref)
+}
+
+func (c *orderWorkflowsClient) SignalApprove(ctx context.Context, ref cadence.ExecutionRef, arg Approval) error {
+	return OrderWorkflowsApproveSignal.Send(ctx, c.api, ref, arg)
+}
+
+// Activity names of PaymentActivities
+const (
+	PaymentActivitiesChargeActivityName = "main.PaymentActivities.Charge"
+)
+
+// RegisterPaymentActivities registers all activities of PaymentActivities with cadence
+func RegisterPaymentActivities(impl PaymentActivities) {
+	cadence.NewActivityDefWithName(PaymentActivitiesChargeActivityName, impl.Charge).Register()
+}
+
+// PaymentActivitiesStub executes the activities of PaymentActivities from a workflow
+type PaymentActivitiesStub interface {
+	Charge(ctx workflow.Context, in Order) (*Receipt, error)
+	ChargeAsync(ctx workflow.Context, in Order) cadence.Future[*Receipt]
+}
+
+// NewPaymentActivitiesStub creates a stub to execute the activities of PaymentActivities from a workflow
+func NewPaymentActivitiesStub() PaymentActivitiesStub {
+	return &paymentActivitiesStub{}
+}
+
+type paymentActivitiesStub struct {
+}
+
+func (s *paymentActivitiesStub) Charge(ctx workflow.Context, in Order) (*Receipt, error) {
+	return cadence.NewActivityDefWithName[Order, *Receipt](PaymentActivitiesChargeActivityName, nil).Execute(ctx, in)
+}
+
+func (s *paymentActivitiesStub) ChargeAsync(ctx workflow.Context, in Order) cadence.Future[*Receipt] {
+	return cadence.NewActivityDefWithName[Order, *Receipt](PaymentActivitiesChargeActivityName, nil).ExecuteAsync(ctx, in)
+}
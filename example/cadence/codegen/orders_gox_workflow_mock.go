@@ -0,0 +1,222 @@
+// Code generated by gox-workflow-gen. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"github.com/golang/mock/gomock"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"reflect"
+)
+
+// MockOrderWorkflows is a mock of OrderWorkflows interface.
+type MockOrderWorkflows struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderWorkflowsMockRecorder
+}
+
+// MockOrderWorkflowsMockRecorder is the mock recorder for MockOrderWorkflows.
+type MockOrderWorkflowsMockRecorder struct {
+	mock *MockOrderWorkflows
+}
+
+// NewMockOrderWorkflows creates a new mock instance.
+func NewMockOrderWorkflows(ctrl *gomock.Controller) *MockOrderWorkflows {
+	mock := &MockOrderWorkflows{ctrl: ctrl}
+	mock.recorder = &MockOrderWorkflowsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderWorkflows) EXPECT() *MockOrderWorkflowsMockRecorder {
+	return m.recorder
+}
+
+// CreateOrder mocks base method.
+func (m *MockOrderWorkflows) CreateOrder(arg0 workflow.Context, arg1 Order) (*Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1)
+	ret0, _ := ret[0].(*Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockOrderWorkflowsMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderWorkflows)(nil).CreateOrder), arg0, arg1)
+}
+
+// MockOrderWorkflowsClient is a mock of OrderWorkflowsClient interface.
+type MockOrderWorkflowsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderWorkflowsClientMockRecorder
+}
+
+// MockOrderWorkflowsClientMockRecorder is the mock recorder for MockOrderWorkflowsClient.
+type MockOrderWorkflowsClientMockRecorder struct {
+	mock *MockOrderWorkflowsClient
+}
+
+// NewMockOrderWorkflowsClient creates a new mock instance.
+func NewMockOrderWorkflowsClient(ctrl *gomock.Controller) *MockOrderWorkflowsClient {
+	mock := &MockOrderWorkflowsClient{ctrl: ctrl}
+	mock.recorder = &MockOrderWorkflowsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderWorkflowsClient) EXPECT() *MockOrderWorkflowsClientMockRecorder {
+	return m.recorder
+}
+
+// StartCreateOrder mocks base method.
+func (m *MockOrderWorkflowsClient) StartCreateOrder(arg0 context.Context, arg1 client.StartWorkflowOptions, arg2 Order) (*cadence.WorkflowHandle[*Receipt], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartCreateOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*cadence.WorkflowHandle[*Receipt])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartCreateOrder indicates an expected call of StartCreateOrder.
+func (mr *MockOrderWorkflowsClientMockRecorder) StartCreateOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartCreateOrder", reflect.TypeOf((*MockOrderWorkflowsClient)(nil).StartCreateOrder), arg0, arg1, arg2)
+}
+
+// ExecuteCreateOrder mocks base method.
+func (m *MockOrderWorkflowsClient) ExecuteCreateOrder(arg0 context.Context, arg1 client.StartWorkflowOptions, arg2 Order) (*Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteCreateOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteCreateOrder indicates an expected call of ExecuteCreateOrder.
+func (mr *MockOrderWorkflowsClientMockRecorder) ExecuteCreateOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteCreateOrder", reflect.TypeOf((*MockOrderWorkflowsClient)(nil).ExecuteCreateOrder), arg0, arg1, arg2)
+}
+
+// QueryStatus mocks base method.
+func (m *MockOrderWorkflowsClient) QueryStatus(arg0 context.Context, arg1 cadence.ExecutionRef) (*Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryStatus", arg0, arg1)
+	ret0, _ := ret[0].(*Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryStatus indicates an expected call of QueryStatus.
+func (mr *MockOrderWorkflowsClientMockRecorder) QueryStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryStatus", reflect.TypeOf((*MockOrderWorkflowsClient)(nil).QueryStatus), arg0, arg1)
+}
+
+// SignalApprove mocks base method.
+func (m *MockOrderWorkflowsClient) SignalApprove(arg0 context.Context, arg1 cadence.ExecutionRef, arg2 Approval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignalApprove", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignalApprove indicates an expected call of SignalApprove.
+func (mr *MockOrderWorkflowsClientMockRecorder) SignalApprove(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignalApprove", reflect.TypeOf((*MockOrderWorkflowsClient)(nil).SignalApprove), arg0, arg1, arg2)
+}
+
+// MockPaymentActivities is a mock of PaymentActivities interface.
+type MockPaymentActivities struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentActivitiesMockRecorder
+}
+
+// MockPaymentActivitiesMockRecorder is the mock recorder for MockPaymentActivities.
+type MockPaymentActivitiesMockRecorder struct {
+	mock *MockPaymentActivities
+}
+
+// NewMockPaymentActivities creates a new mock instance.
+func NewMockPaymentActivities(ctrl *gomock.Controller) *MockPaymentActivities {
+	mock := &MockPaymentActivities{ctrl: ctrl}
+	mock.recorder = &MockPaymentActivitiesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentActivities) EXPECT() *MockPaymentActivitiesMockRecorder {
+	return m.recorder
+}
+
+// Charge mocks base method.
+func (m *MockPaymentActivities) Charge(arg0 context.Context, arg1 Order) (*Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", arg0, arg1)
+	ret0, _ := ret[0].(*Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentActivitiesMockRecorder) Charge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentActivities)(nil).Charge), arg0, arg1)
+}
+
+// MockPaymentActivitiesStub is a mock of PaymentActivitiesStub interface.
+type MockPaymentActivitiesStub struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentActivitiesStubMockRecorder
+}
+
+// MockPaymentActivitiesStubMockRecorder is the mock recorder for MockPaymentActivitiesStub.
+type MockPaymentActivitiesStubMockRecorder struct {
+	mock *MockPaymentActivitiesStub
+}
+
+// NewMockPaymentActivitiesStub creates a new mock instance.
+func NewMockPaymentActivitiesStub(ctrl *gomock.Controller) *MockPaymentActivitiesStub {
+	mock := &MockPaymentActivitiesStub{ctrl: ctrl}
+	mock.recorder = &MockPaymentActivitiesStubMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentActivitiesStub) EXPECT() *MockPaymentActivitiesStubMockRecorder {
+	return m.recorder
+}
+
+// Charge mocks base method.
+func (m *MockPaymentActivitiesStub) Charge(arg0 workflow.Context, arg1 Order) (*Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", arg0, arg1)
+	ret0, _ := ret[0].(*Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentActivitiesStubMockRecorder) Charge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentActivitiesStub)(nil).Charge), arg0, arg1)
+}
+
+// ChargeAsync mocks base method.
+func (m *MockPaymentActivitiesStub) ChargeAsync(arg0 workflow.Context, arg1 Order) cadence.Future[*Receipt] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeAsync", arg0, arg1)
+	ret0, _ := ret[0].(cadence.Future[*Receipt])
+	return ret0
+}
+
+// ChargeAsync indicates an expected call of ChargeAsync.
+func (mr *MockPaymentActivitiesStubMockRecorder) ChargeAsync(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeAsync", reflect.TypeOf((*MockPaymentActivitiesStub)(nil).ChargeAsync), arg0, arg1)
+}
@@ -1,5 +1,15 @@
+// gox-workflow is a command line tool to operate cadence workflows using the same YAML config (cadence.Config) as the
+// application. See package cli for the commands.
+//
+//	go build -o gox-workflow github.com/devlibx/gox-workfkow
+//	gox-workflow -config config.yaml -o table list -query "CloseTime = missing"
 package main
 
-func main() {
+import (
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence/cli"
+	"os"
+)
 
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/devlibx/gox-base/v2/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Operation names used in Operation.Name
+const (
+	OperationStart     = "start"
+	OperationSignal    = "signal"
+	OperationQuery     = "query"
+	OperationCancel    = "cancel"
+	OperationTerminate = "terminate"
+	OperationDescribe  = "describe"
+	OperationList      = "list"
+	OperationHealth    = "health"
+)
+
+// Principal is the authenticated caller
+type Principal struct {
+	Subject    string
+	Roles      []string
+	Attributes map[string]string
+}
+
+// HasRole returns true if the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymous is used when no Authenticator is set
+var anonymous = &Principal{Subject: "anonymous"}
+
+// Operation is the workflow operation requested by the caller - it is given to the Authorizer and audit log
+type Operation struct {
+	Name         string `json:"name"`
+	TaskList     string `json:"task_list,omitempty"`
+	WorkflowType string `json:"workflow_type,omitempty"`
+	WorkflowID   string `json:"workflow_id,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+	SignalName   string `json:"signal_name,omitempty"`
+	QueryType    string `json:"query_type,omitempty"`
+}
+
+// Authenticator finds the caller of the request. Return an error to reject the request with 401.
+type Authenticator func(r *http.Request) (*Principal, error)
+
+// Authorizer checks if the principal can perform the operation. Return an error to reject the request with 403.
+type Authorizer func(ctx context.Context, principal *Principal, op Operation) error
+
+// AuditRecord is given to the audit function after every request
+type AuditRecord struct {
+	Time       time.Time
+	Principal  *Principal
+	Operation  Operation
+	RemoteAddr string
+	Status     int
+	Error      string
+	Duration   time.Duration
+}
+
+// AuditLogger is called after every request (including rejected requests)
+type AuditLogger func(ctx context.Context, record AuditRecord)
+
+// StaticTokenAuthenticator authenticates "Authorization: Bearer <token>" requests using a fixed set of tokens
+func StaticTokenAuthenticator(tokens map[string]*Principal) Authenticator {
+	return func(r *http.Request) (*Principal, error) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || len(token) == 0 {
+			return nil, errors.New("missing bearer token")
+		}
+		for t, p := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return p, nil
+			}
+		}
+		return nil, errors.New("invalid bearer token")
+	}
+}
+
+// ReadOnlyAuthorizer allows the operations which do not change a workflow (describe, list, query and health) - it is
+// the default authorizer of the handler
+func ReadOnlyAuthorizer() Authorizer {
+	return func(ctx context.Context, principal *Principal, op Operation) error {
+		switch op.Name {
+		case OperationDescribe, OperationList, OperationQuery, OperationHealth:
+			return nil
+		}
+		return errors.New("%s is not allowed without an authorizer - set one with WithAuthorizer (or WithInsecureAllowAll)", op.Name)
+	}
+}
+
+// RoleAuthorizer allows an operation if the principal has one of the roles given for the operation name. Operations
+// not in the map are rejected.
+func RoleAuthorizer(roles map[string][]string) Authorizer {
+	return func(ctx context.Context, principal *Principal, op Operation) error {
+		for _, role := range roles[op.Name] {
+			if principal.HasRole(role) {
+				return nil
+			}
+		}
+		return errors.New("principal %s is not allowed to %s", principal.Subject, op.Name)
+	}
+}
@@ -0,0 +1,15 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RegisterGin mounts the handler on the gin router under the prefix e.g. RegisterGin(router, "/admin", h) serves
+// "/admin/workflows/..." and "/admin/health". All the paths under the prefix are passed to the handler so the routes
+// are defined only by the handler.
+func RegisterGin(router gin.IRouter, prefix string, h http.Handler) {
+	group := router.Group(prefix)
+	handler := gin.WrapH(http.StripPrefix(group.BasePath(), h))
+	group.Any("/*path", handler)
+}
@@ -0,0 +1,529 @@
+// Package admin provides a mountable http.Handler which exposes the cadence.Api as a REST API, so that internal tools
+// can operate workflows without linking Go code.
+//
+// Routes (relative to the mount path):
+//
+//	POST /workflows                              start a workflow - body is StartRequest
+//	GET  /workflows?task_list=&query=            list workflows using a visibility query
+//	GET  /workflows/{id}?task_list=&run_id=      describe a workflow
+//	POST /workflows/{id}/signal/{name}?task_list=&run_id=      body is the signal input (JSON)
+//	POST /workflows/{id}/query/{type}?task_list=&run_id=       body is the query argument (JSON, optional)
+//	POST /workflows/{id}/cancel?task_list=&run_id=
+//	POST /workflows/{id}/terminate?task_list=&run_id=          body is {"reason": "..."} (optional)
+//
+// Every request is authenticated (Authenticator), authorized (Authorizer) and audit logged (AuditLogger). Without an
+// Authorizer only the read operations (describe, list, query and health) are allowed.
+//
+//	h := admin.NewHandler(workflowApi, admin.WithAuthenticator(...), admin.WithAuthorizer(...))
+//	mux.Handle("/admin/", http.StripPrefix("/admin", h))   // net/http
+//	admin.RegisterGin(router, "/admin", h)                 // gin
+package admin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExecutionTimeout = time.Hour
+	defaultDecisionTimeout  = 10 * time.Second
+	maxBodySize             = 1 << 20
+)
+
+// Handler is the http.Handler for the admin REST API
+type Handler struct {
+	api           cadence.Api
+	mux           *http.ServeMux
+	authenticator Authenticator
+	authorizer    Authorizer
+	audit         AuditLogger
+	logger        *slog.Logger
+	workflowTypes map[string]bool
+}
+
+// Option is used to customise the handler created by NewHandler
+type Option func(h *Handler)
+
+// WithAuthenticator sets the authenticator - if not set all the requests are from an "anonymous" principal
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(h *Handler) {
+		h.authenticator = authenticator
+	}
+}
+
+// WithAuthorizer sets the authorizer - if not set only the read operations are allowed (see ReadOnlyAuthorizer)
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(h *Handler) {
+		h.authorizer = authorizer
+	}
+}
+
+// WithInsecureAllowAll allows all the operations to every caller. Use it only when the handler is protected by other
+// means (e.g. a private network or an auth middleware).
+func WithInsecureAllowAll() Option {
+	return func(h *Handler) {
+		h.authorizer = func(ctx context.Context, principal *Principal, op Operation) error {
+			return nil
+		}
+	}
+}
+
+// WithAuditLogger sets the audit logger - default writes an info log for every request with the logger
+func WithAuditLogger(audit AuditLogger) Option {
+	return func(h *Handler) {
+		h.audit = audit
+	}
+}
+
+// WithLogger sets the logger used by the default audit logger (default slog.Default())
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// WithWorkflowTypes sets the workflow types which can be started. By default only the workflows registered in this
+// process (workflow.Register) can be started - use this option if the process does not register the workflows.
+func WithWorkflowTypes(workflowTypes ...string) Option {
+	return func(h *Handler) {
+		h.workflowTypes = map[string]bool{}
+		for _, wt := range workflowTypes {
+			h.workflowTypes[wt] = true
+		}
+	}
+}
+
+// NewHandler creates the admin REST API handler
+func NewHandler(api cadence.Api, opts ...Option) *Handler {
+	h := &Handler{api: api, mux: http.NewServeMux(), logger: slog.Default()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.audit == nil {
+		h.audit = h.logAudit
+	}
+	if h.authorizer == nil {
+		h.authorizer = ReadOnlyAuthorizer()
+	}
+
+	h.mux.HandleFunc("POST /workflows", h.route(OperationStart, h.start))
+	h.mux.HandleFunc("GET /workflows", h.route(OperationList, h.list))
+	h.mux.HandleFunc("GET /workflows/{id}", h.route(OperationDescribe, h.describe))
+	h.mux.HandleFunc("POST /workflows/{id}/signal/{name}", h.route(OperationSignal, h.signal))
+	h.mux.HandleFunc("POST /workflows/{id}/query/{name}", h.route(OperationQuery, h.query))
+	h.mux.HandleFunc("POST /workflows/{id}/cancel", h.route(OperationCancel, h.cancel))
+	h.mux.HandleFunc("POST /workflows/{id}/terminate", h.route(OperationTerminate, h.terminate))
+	h.mux.HandleFunc("GET /health", h.route(OperationHealth, h.health))
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// StartRequest is the body to start a workflow
+type StartRequest struct {
+	WorkflowType string `json:"workflow_type"`
+	TaskList     string `json:"task_list"`
+
+	// WorkflowID is optional - a random id is used if not set
+	WorkflowID string `json:"workflow_id"`
+
+	// Input is the workflow input - use Args if the workflow takes more than one argument
+	Input json.RawMessage   `json:"input"`
+	Args  []json.RawMessage `json:"args"`
+
+	// ExecutionTimeout (default 1h) and DecisionTimeout (default 10s) are durations e.g. "30m"
+	ExecutionTimeout string `json:"execution_timeout"`
+	DecisionTimeout  string `json:"decision_timeout"`
+	CronSchedule     string `json:"cron_schedule"`
+
+	// Wait if true waits for the workflow to complete and returns the result
+	Wait bool `json:"wait"`
+}
+
+// StartResponse is the response of start workflow
+type StartResponse struct {
+	WorkflowID string      `json:"workflow_id"`
+	RunID      string      `json:"run_id"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// ListResponse is the response of list workflows
+type ListResponse struct {
+	Executions    []*shared.WorkflowExecutionInfo `json:"executions"`
+	NextPageToken string                          `json:"next_page_token,omitempty"`
+}
+
+// action runs the operation after the request is authorized
+type action func(ctx context.Context) (interface{}, error)
+
+// statusError is an error with the http status to return
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(err error) error {
+	return &statusError{status: http.StatusBadRequest, err: err}
+}
+
+// route authenticates the request, parses it (parse fills the operation), authorizes the operation, runs the action
+// and writes the audit log
+func (h *Handler) route(name string, parse func(r *http.Request, op *Operation) (action, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		op := Operation{Name: name}
+		principal := anonymous
+
+		result, err := func() (interface{}, error) {
+			if h.authenticator != nil {
+				p, err := h.authenticator(r)
+				if err != nil {
+					return nil, &statusError{status: http.StatusUnauthorized, err: err}
+				}
+				principal = p
+			}
+
+			run, err := parse(r, &op)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := h.authorizer(r.Context(), principal, op); err != nil {
+				return nil, &statusError{status: http.StatusForbidden, err: err}
+			}
+			return run(r.Context())
+		}()
+
+		status := http.StatusOK
+		record := AuditRecord{Time: start, Principal: principal, Operation: op, RemoteAddr: r.RemoteAddr}
+		if err != nil {
+			status = errorStatus(err)
+			record.Error = err.Error()
+			writeJson(w, status, map[string]string{"error": err.Error()})
+		} else {
+			writeJson(w, status, result)
+		}
+		record.Status = status
+		record.Duration = time.Since(start)
+		h.audit(r.Context(), record)
+	}
+}
+
+func (h *Handler) start(r *http.Request, op *Operation) (action, error) {
+	req := StartRequest{}
+	if err := readJson(r, &req); err != nil {
+		return nil, err
+	}
+	op.WorkflowType, op.TaskList, op.WorkflowID = req.WorkflowType, req.TaskList, req.WorkflowID
+
+	if len(req.WorkflowType) == 0 || len(req.TaskList) == 0 {
+		return nil, badRequest(errors.New("workflow_type and task_list are required"))
+	}
+	if !h.workflowTypeAllowed(req.WorkflowType) {
+		return nil, badRequest(errors.New("workflow type %s is not registered", req.WorkflowType))
+	}
+
+	options := client.StartWorkflowOptions{
+		ID:                              req.WorkflowID,
+		TaskList:                        req.TaskList,
+		ExecutionStartToCloseTimeout:    defaultExecutionTimeout,
+		DecisionTaskStartToCloseTimeout: defaultDecisionTimeout,
+		CronSchedule:                    req.CronSchedule,
+	}
+	var err error
+	if len(req.ExecutionTimeout) > 0 {
+		if options.ExecutionStartToCloseTimeout, err = time.ParseDuration(req.ExecutionTimeout); err != nil {
+			return nil, badRequest(errors.Wrap(err, "bad execution_timeout"))
+		}
+	}
+	if len(req.DecisionTimeout) > 0 {
+		if options.DecisionTaskStartToCloseTimeout, err = time.ParseDuration(req.DecisionTimeout); err != nil {
+			return nil, badRequest(errors.Wrap(err, "bad decision_timeout"))
+		}
+	}
+
+	var args []interface{}
+	if len(req.Input) > 0 {
+		args = append(args, req.Input)
+	}
+	for _, arg := range req.Args {
+		args = append(args, arg)
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		if !req.Wait {
+			execution, err := h.api.StartWorkflow(ctx, options, req.WorkflowType, args...)
+			if err != nil {
+				return nil, err
+			}
+			return &StartResponse{WorkflowID: execution.ID, RunID: execution.RunID}, nil
+		}
+
+		run, err := h.api.ExecuteWorkflow(ctx, options, req.WorkflowType, args...)
+		if err != nil {
+			return nil, err
+		}
+		response := &StartResponse{WorkflowID: run.GetID(), RunID: run.GetRunID()}
+		if err := run.Get(ctx, &response.Result); err != nil {
+			response.Error = err.Error()
+		}
+		return response, nil
+	}, nil
+}
+
+// health returns the circuit breaker state of the worker groups - the status is 503 if a circuit is open
+func (h *Handler) health(r *http.Request, op *Operation) (action, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		groups, err := h.api.Health(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			if !g.Healthy {
+				return nil, &statusError{status: http.StatusServiceUnavailable, err: errors.New("circuit breaker is open - workerGroup=%s", g.Name)}
+			}
+		}
+		return groups, nil
+	}, nil
+}
+
+func (h *Handler) list(r *http.Request, op *Operation) (action, error) {
+	op.TaskList = r.URL.Query().Get("task_list")
+	if len(op.TaskList) == 0 {
+		return nil, badRequest(errors.New("task_list is required"))
+	}
+
+	query := r.URL.Query().Get("query")
+	request := &shared.ListWorkflowExecutionsRequest{Query: &query}
+	if v := r.URL.Query().Get("page_size"); len(v) > 0 {
+		pageSize, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, badRequest(errors.Wrap(err, "bad page_size"))
+		}
+		size := int32(pageSize)
+		request.PageSize = &size
+	}
+	if v := r.URL.Query().Get("next_page_token"); len(v) > 0 {
+		token, err := base64.URLEncoding.DecodeString(v)
+		if err != nil {
+			return nil, badRequest(errors.Wrap(err, "bad next_page_token"))
+		}
+		request.NextPageToken = token
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		response, err := h.api.ListWorkflow(withTaskList(ctx, op), request)
+		if err != nil {
+			return nil, err
+		}
+		out := &ListResponse{Executions: response.Executions}
+		if out.Executions == nil {
+			out.Executions = []*shared.WorkflowExecutionInfo{}
+		}
+		if len(response.NextPageToken) > 0 {
+			out.NextPageToken = base64.URLEncoding.EncodeToString(response.NextPageToken)
+		}
+		return out, nil
+	}, nil
+}
+
+func (h *Handler) describe(r *http.Request, op *Operation) (action, error) {
+	if err := workflowOperation(r, op); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (interface{}, error) {
+		return h.api.DescribeWorkflowExecution(withTaskList(ctx, op), op.WorkflowID, op.RunID)
+	}, nil
+}
+
+func (h *Handler) signal(r *http.Request, op *Operation) (action, error) {
+	if err := workflowOperation(r, op); err != nil {
+		return nil, err
+	}
+	op.SignalName = r.PathValue("name")
+
+	var input json.RawMessage
+	if err := readJson(r, &input); err != nil {
+		return nil, err
+	}
+	var arg interface{}
+	if len(input) > 0 {
+		arg = input
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		if err := h.api.SignalWorkflow(withTaskList(ctx, op), op.WorkflowID, op.RunID, op.SignalName, arg); err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "signaled"}, nil
+	}, nil
+}
+
+func (h *Handler) query(r *http.Request, op *Operation) (action, error) {
+	if err := workflowOperation(r, op); err != nil {
+		return nil, err
+	}
+	op.QueryType = r.PathValue("name")
+
+	var input json.RawMessage
+	if err := readJson(r, &input); err != nil {
+		return nil, err
+	}
+	var args []interface{}
+	if len(input) > 0 {
+		args = append(args, input)
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		value, err := h.api.QueryWorkflow(withTaskList(ctx, op), op.WorkflowID, op.RunID, op.QueryType, args...)
+		if err != nil {
+			return nil, err
+		}
+		var result interface{}
+		if value != nil && value.HasValue() {
+			if err := value.Get(&result); err != nil {
+				return nil, errors.Wrap(err, "failed to decode query result")
+			}
+		}
+		return map[string]interface{}{"result": result}, nil
+	}, nil
+}
+
+func (h *Handler) cancel(r *http.Request, op *Operation) (action, error) {
+	if err := workflowOperation(r, op); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (interface{}, error) {
+		if err := h.api.CancelWorkflow(withTaskList(ctx, op), op.WorkflowID, op.RunID); err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "canceled"}, nil
+	}, nil
+}
+
+func (h *Handler) terminate(r *http.Request, op *Operation) (action, error) {
+	if err := workflowOperation(r, op); err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := readJson(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Reason) == 0 {
+		req.Reason = "terminated using admin api"
+	}
+
+	return func(ctx context.Context) (interface{}, error) {
+		if err := h.api.TerminateWorkflow(withTaskList(ctx, op), op.WorkflowID, op.RunID, req.Reason, nil); err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "terminated"}, nil
+	}, nil
+}
+
+func (h *Handler) workflowTypeAllowed(workflowType string) bool {
+	if h.workflowTypes != nil {
+		return h.workflowTypes[workflowType]
+	}
+	for _, wt := range workflow.GetRegisteredWorkflowTypes() {
+		if wt == workflowType {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) logAudit(ctx context.Context, record AuditRecord) {
+	h.logger.InfoContext(ctx, "cadence admin api",
+		slog.String("principal", record.Principal.Subject),
+		slog.String("operation", record.Operation.Name),
+		slog.Any("details", record.Operation),
+		slog.String("remoteAddr", record.RemoteAddr),
+		slog.Int("status", record.Status),
+		slog.String("error", record.Error),
+		slog.Duration("duration", record.Duration),
+	)
+}
+
+// workflowOperation reads the workflow id (path), task list and run id (query params)
+func workflowOperation(r *http.Request, op *Operation) error {
+	op.WorkflowID = r.PathValue("id")
+	op.TaskList = r.URL.Query().Get("task_list")
+	op.RunID = r.URL.Query().Get("run_id")
+	if len(op.TaskList) == 0 {
+		return badRequest(errors.New("task_list is required"))
+	}
+	return nil
+}
+
+// withTaskList sets the task list in the context - it is used by the cadence.Api to find the worker group
+func withTaskList(ctx context.Context, op *Operation) context.Context {
+	return context.WithValue(ctx, cadence.TaskListForAction, op.TaskList)
+}
+
+// readJson reads the JSON body - empty body is allowed
+func readJson(r *http.Request, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return badRequest(errors.Wrap(err, "failed to read body"))
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return badRequest(errors.Wrap(err, "body is not a valid JSON"))
+	}
+	return nil
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errorStatus maps the errors to http status
+func errorStatus(err error) int {
+	var se *statusError
+	var notExists *shared.EntityNotExistsError
+	var badRequest *shared.BadRequestError
+	var alreadyStarted *shared.WorkflowExecutionAlreadyStartedError
+	var queryFailed *shared.QueryFailedError
+	switch {
+	case errors.As(err, &se):
+		return se.status
+	case errors.As(err, &notExists):
+		return http.StatusNotFound
+	case errors.As(err, &badRequest), errors.As(err, &queryFailed):
+		return http.StatusBadRequest
+	case errors.As(err, &alreadyStarted):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "task list not registered"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,9 +3,11 @@ package cadence
 import (
 	"context"
 	"github.com/devlibx/gox-base/v2"
+	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/client"
 	"go.uber.org/cadence/encoded"
 	"go.uber.org/cadence/workflow"
+	"time"
 )
 
 const TaskListForAction = "__task_list_for_action__"
@@ -15,6 +17,13 @@ type Config struct {
 	EnableErrorStackInCadenceLog bool                   `json:"enable_error_stack_in_cadence_log" yaml:"enable_error_stack_in_cadence_log"`
 	Disabled                     bool                   `json:"disabled" yaml:"disabled"`
 	WorkerGroups                 map[string]WorkerGroup `json:"worker_groups" yaml:"worker_groups"`
+	Logging                      LoggingConfig          `json:"logging" yaml:"logging"`
+
+	// BuildID is the binary checksum of the workers (default from ldflags, env or VCS info - see ResolveBuildID)
+	BuildID string `json:"build_id" yaml:"build_id"`
+
+	// Client is the retry policy and the circuit breaker of the Api calls
+	Client ClientConfig `json:"client" yaml:"client"`
 }
 
 // WorkerGroup is the configuration for Cadence worker group. It allows application to use more than one cadence
@@ -24,8 +33,27 @@ type WorkerGroup struct {
 	Disabled bool      `json:"disabled" yaml:"disabled"`
 	Name     string    `json:"name" yaml:"name"`
 	Domain   string    `json:"domain" yaml:"domain"`
-	HostPort string    `json:"host_port" yaml:"host_port"`
 	Workers  []*Worker `json:"worker" yaml:"worker"`
+
+	// HostPort is a comma separated list of cadence frontend host:port. Host names are resolved to all their
+	// addresses and the requests are load balanced across them.
+	HostPort string `json:"host_port" yaml:"host_port"`
+
+	// HostResolveInterval is how often the host names of HostPort are resolved again (default 30s)
+	HostResolveInterval time.Duration `json:"host_resolve_interval" yaml:"host_resolve_interval"`
+
+	// Shadow enables shadow mode for all workers of this group (can be overridden by a worker)
+	Shadow *ShadowConfig `json:"shadow" yaml:"shadow"`
+
+	// Schedules are the cron workflows of this group - they are reconciled when the worker group starts
+	Schedules []*Schedule `json:"schedules" yaml:"schedules"`
+
+	// Clusters are the clusters of a global domain - they are used in place of HostPort. The worker group connects
+	// to the active cluster of the domain and moves to the new active cluster on a failover (see Cluster).
+	Clusters []*Cluster `json:"clusters" yaml:"clusters"`
+
+	// FailoverCheckInterval is how often the active cluster of the domain is checked (default 30s)
+	FailoverCheckInterval time.Duration `json:"failover_check_interval" yaml:"failover_check_interval"`
 }
 
 // Worker is the configuration for Cadence worker
@@ -33,6 +61,19 @@ type Worker struct {
 	Disabled    bool   `json:"disabled" yaml:"disabled"`
 	TaskList    string `json:"task_list" yaml:"task_list"`
 	WorkerCount int    `json:"worker_count" yaml:"worker_count"`
+
+	// Tuning of the cadence worker - zero values keep the cadence defaults. They can be changed with a config
+	// reload (the task list worker is restarted).
+	ActivitiesPerSecond         float64 `json:"activities_per_second" yaml:"activities_per_second"`
+	TaskListActivitiesPerSecond float64 `json:"task_list_activities_per_second" yaml:"task_list_activities_per_second"`
+	DecisionsPerSecond          float64 `json:"decisions_per_second" yaml:"decisions_per_second"`
+	MaxConcurrentActivities     int     `json:"max_concurrent_activities" yaml:"max_concurrent_activities"`
+	MaxConcurrentDecisions      int     `json:"max_concurrent_decisions" yaml:"max_concurrent_decisions"`
+	ActivityPollers             int     `json:"activity_pollers" yaml:"activity_pollers"`
+	DecisionPollers             int     `json:"decision_pollers" yaml:"decision_pollers"`
+
+	// Shadow enables shadow mode for this worker - see ShadowConfig
+	Shadow *ShadowConfig `json:"shadow" yaml:"shadow"`
 }
 
 // Api is the interface for Cadence client. It is used to avoid direct dependency on Cadence client in the application code.
@@ -70,6 +111,24 @@ type Api interface {
 	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
 	QueryWorkflow(ctx context.Context, workflowID string, runID string, queryType string, args ...interface{}) (encoded.Value, error)
 
+	// GetWorkflow returns a WorkflowRun for an existing workflow execution, which can be used to wait for the result
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	GetWorkflow(ctx context.Context, workflowID string, runID string) (client.WorkflowRun, error)
+
+	// SignalWorkflow sends a signal to a workflow execution
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error
+
 	// TerminateWorkflow queries a workflow execution
 	//
 	// IMPORTANT REQUIREMENT:
@@ -78,9 +137,100 @@ type Api interface {
 	// e.g.
 	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
 	TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error
+
+	// GetWorkflowHistory returns all the history events of a workflow execution
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	GetWorkflowHistory(ctx context.Context, workflowID string, runID string) (*shared.History, error)
+
+	// ListWorkflow lists workflow executions using a visibility query. Domain is set from the worker group if it is
+	// empty in the request.
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	ListWorkflow(ctx context.Context, request *shared.ListWorkflowExecutionsRequest) (*shared.ListWorkflowExecutionsResponse, error)
+
+	// DescribeWorkflowExecution returns the information about a workflow execution
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	DescribeWorkflowExecution(ctx context.Context, workflowID string, runID string) (*shared.DescribeWorkflowExecutionResponse, error)
+
+	// ResetWorkflow resets a workflow execution to the given decision finish event. Domain is set from the worker group
+	// if it is empty in the request.
+	//
+	// IMPORTANT REQUIREMENT:
+	// Since this is a cadence wrapper, you will have to pass the task list name to perform the action.
+	//
+	// e.g.
+	// ctx := context.WithValue(context.Background(), cadence.TaskListForAction, "server_2_ts_1")
+	ResetWorkflow(ctx context.Context, request *shared.ResetWorkflowExecutionRequest) (*shared.ResetWorkflowExecutionResponse, error)
+
+	// ListSchedules returns the schedules of all worker groups with their state and next fire times
+	ListSchedules(ctx context.Context) ([]ScheduleInfo, error)
+
+	// PauseSchedule stops the schedule until ResumeSchedule is called. A running workflow started by the schedule is
+	// not stopped. The schedule stays paused across restarts of the application.
+	PauseSchedule(ctx context.Context, name string) error
+
+	// ResumeSchedule starts a paused schedule again
+	ResumeSchedule(ctx context.Context, name string) error
+
+	// MarkBadBinary marks the binary checksum (build id) as bad on the domain - workers of the binary stop getting
+	// decision tasks of the domain
+	MarkBadBinary(ctx context.Context, domain string, checksum string, reason string) error
+
+	// RemoveBadBinary removes the binary checksum from the bad binaries of the domain
+	RemoveBadBinary(ctx context.Context, domain string, checksum string) error
+
+	// ListBadBinaries returns the bad binaries of the domain
+	ListBadBinaries(ctx context.Context, domain string) ([]BadBinary, error)
+
+	// AddWorker adds a task list worker to a running worker group and starts its pollers
+	AddWorker(ctx context.Context, workerGroup string, worker Worker) error
+
+	// RemoveWorker stops the task list worker and removes the task list - workflows of the task list can not be
+	// started or operated with this client after it is removed
+	RemoveWorker(ctx context.Context, taskList string) error
+
+	// PauseWorker stops the pollers of the task list. Workflows can still be started and signalled; their tasks wait
+	// in the task list until ResumeWorker is called.
+	PauseWorker(ctx context.Context, taskList string) error
+
+	// ResumeWorker starts the pollers of a paused task list again
+	ResumeWorker(ctx context.Context, taskList string) error
+
+	// ReloadConfig validates the new config and applies the changes live: worker groups and task list workers are
+	// started or stopped, and workers with changed tuning are restarted. If any change needs a restart of the
+	// application, nothing is applied and the report lists those changes.
+	ReloadConfig(ctx context.Context, config *Config) (*ConfigReloadReport, error)
+
+	// Health returns the state of the circuit breaker (and the cluster) of each running worker group
+	Health(ctx context.Context) ([]WorkerGroupHealth, error)
+}
+
+// Option is used to customise the cadence client created by NewCadenceClient
+type Option func(wrapper *cadenceWrapperImpl)
+
+// WithClientOnly creates only the cadence clients - the workers (pollers) are not started. Use it in the
+// applications (e.g. CLI, API servers) which only start or operate workflows.
+func WithClientOnly() Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		wrapper.clientOnly = true
+	}
 }
 
-func NewCadenceClient(cf gox.CrossFunction, config *Config) (Api, error) {
+func NewCadenceClient(cf gox.CrossFunction, config *Config, opts ...Option) (Api, error) {
 	if config.Disabled {
 		return &noOpCadenceApi{}, nil
 	}
@@ -89,6 +239,9 @@ func NewCadenceClient(cf gox.CrossFunction, config *Config) (Api, error) {
 		CrossFunction: cf,
 		config:        config,
 	}
+	for _, opt := range opts {
+		opt(impl)
+	}
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,338 @@
+// Package approval adds human approval steps to workflows: wait for approve/reject signals from named approvers
+// with a quorum, reminders, escalation and a deadline.
+//
+// Workflow side:
+//
+//	approvals, err := approval.NewManager(ctx)
+//	result, err := approvals.Wait(ctx, approval.Request{
+//		ID:               "refund",
+//		Approvers:        []string{"alice", "bob", "carol"},
+//		Quorum:           2,
+//		NotifyActivity:   "main.NotifyApprovers",
+//		ReminderInterval: 4 * time.Hour,
+//		EscalateAfter:    24 * time.Hour,
+//		EscalateTo:       []string{"finance-lead"},
+//		Deadline:         72 * time.Hour,
+//	})
+//	if result.Status != approval.StatusApproved { ... }
+//
+// Caller side (see Client): client.Approve(ctx, workflowID, "", "refund", "alice", "looks good")
+package approval
+
+import (
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/workflow"
+	"sort"
+	"time"
+)
+
+const (
+	// QueryPending returns the pending approvals of a workflow ([]Pending)
+	QueryPending = "gox_pending_approvals"
+
+	// signalPrefix is the prefix of the signal name of an approval request (see SignalName)
+	signalPrefix = "gox_approval:"
+
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+
+	NotificationRequested = "requested"
+	NotificationReminder  = "reminder"
+	NotificationEscalated = "escalated"
+)
+
+// SignalName returns the signal name used for decisions of an approval request
+func SignalName(requestID string) string {
+	return signalPrefix + requestID
+}
+
+// Request is an approval step
+type Request struct {
+	// ID identifies the request in the workflow - approve/reject signals are sent for this id
+	ID string
+
+	// Approvers who can decide - empty allows anyone
+	Approvers []string
+
+	// Quorum is the number of approvals needed (default 1). A single rejection rejects the request.
+	Quorum int
+
+	// Details are shown in the pending query and sent in notifications
+	Details interface{}
+
+	// NotifyActivity (function or registered name) is called with a Notification when the request is created, for
+	// every reminder and on escalation. Notifications are not sent if it is nil.
+	NotifyActivity interface{}
+
+	// ReminderInterval sends a reminder to the approvers who did not decide yet (0 disables reminders)
+	ReminderInterval time.Duration
+
+	// EscalateAfter adds EscalateTo to the approvers and notifies them (0 disables escalation)
+	EscalateAfter time.Duration
+	EscalateTo    []string
+
+	// Deadline expires the request (0 waits forever)
+	Deadline time.Duration
+}
+
+// Decision is the signal sent by an approver
+type Decision struct {
+	Approver string    `json:"approver"`
+	Approve  bool      `json:"approve"`
+	Comment  string    `json:"comment,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Result is the outcome of an approval request
+type Result struct {
+	Status    string     `json:"status"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// Pending is a request which is waiting for decisions
+type Pending struct {
+	ID          string      `json:"id"`
+	Approvers   []string    `json:"approvers"`
+	Quorum      int         `json:"quorum"`
+	Approvals   int         `json:"approvals"`
+	Waiting     []string    `json:"waiting"`
+	Escalated   bool        `json:"escalated"`
+	RequestedAt time.Time   `json:"requested_at"`
+	Deadline    *time.Time  `json:"deadline,omitempty"`
+	Details     interface{} `json:"details,omitempty"`
+}
+
+// Notification is given to the notify activity
+type Notification struct {
+	Kind       string      `json:"kind"`
+	RequestID  string      `json:"request_id"`
+	WorkflowID string      `json:"workflow_id"`
+	RunID      string      `json:"run_id"`
+	Approvers  []string    `json:"approvers"`
+	Waiting    []string    `json:"waiting"`
+	Reminder   int         `json:"reminder,omitempty"`
+	Details    interface{} `json:"details,omitempty"`
+}
+
+// Manager keeps the approval requests of a workflow and serves the pending query. Create one per workflow execution.
+type Manager struct {
+	pending map[string]*Pending
+}
+
+// NewManager creates the manager and registers the pending approvals query
+func NewManager(ctx workflow.Context) (*Manager, error) {
+	m := &Manager{pending: map[string]*Pending{}}
+	err := workflow.SetQueryHandler(ctx, QueryPending, func() ([]Pending, error) {
+		result := make([]Pending, 0, len(m.pending))
+		for _, p := range m.pending {
+			result = append(result, *p)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+		return result, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to register pending approvals query")
+	}
+	return m, nil
+}
+
+// Wait waits until the request is approved (quorum reached), rejected or expired. Decisions from approvers who are not
+// allowed, and repeated decisions from the same approver, are ignored.
+func (m *Manager) Wait(ctx workflow.Context, req Request) (*Result, error) {
+	if len(req.ID) == 0 {
+		return nil, errors.New("approval request id is required")
+	}
+	if _, ok := m.pending[req.ID]; ok {
+		return nil, errors.New("approval request is already pending: %s", req.ID)
+	}
+	if req.Quorum <= 0 {
+		req.Quorum = 1
+	}
+
+	w := &waiter{req: req, decided: map[string]bool{}, info: workflow.GetInfo(ctx)}
+	w.pending = &Pending{
+		ID:          req.ID,
+		Approvers:   append([]string(nil), req.Approvers...),
+		Quorum:      req.Quorum,
+		RequestedAt: workflow.Now(ctx),
+		Details:     req.Details,
+	}
+	if req.Deadline > 0 {
+		deadline := w.pending.RequestedAt.Add(req.Deadline)
+		w.pending.Deadline = &deadline
+	}
+	w.updateWaiting()
+
+	m.pending[req.ID] = w.pending
+	defer delete(m.pending, req.ID)
+
+	return w.wait(ctx)
+}
+
+type waiter struct {
+	req       Request
+	pending   *Pending
+	decided   map[string]bool
+	decisions []Decision
+	reminders int
+	info      *workflow.Info
+}
+
+func (w *waiter) wait(ctx workflow.Context) (*Result, error) {
+	ctx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	w.notify(ctx, NotificationRequested)
+
+	signals := workflow.GetSignalChannel(ctx, SignalName(w.req.ID))
+	var expired, escalate, remind bool
+	var reminderTimer workflow.Future
+	if w.req.ReminderInterval > 0 {
+		reminderTimer = workflow.NewTimer(ctx, w.req.ReminderInterval)
+	}
+	var escalationTimer workflow.Future
+	if w.req.EscalateAfter > 0 {
+		escalationTimer = workflow.NewTimer(ctx, w.req.EscalateAfter)
+	}
+	var deadlineTimer workflow.Future
+	if w.req.Deadline > 0 {
+		deadlineTimer = workflow.NewTimer(ctx, w.req.Deadline)
+	}
+
+	for {
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(signals, func(c workflow.Channel, more bool) {
+			var d Decision
+			c.Receive(ctx, &d)
+			w.decide(ctx, d)
+		})
+		selector.AddReceive(ctx.Done(), func(c workflow.Channel, more bool) {})
+		if reminderTimer != nil {
+			selector.AddFuture(reminderTimer, func(f workflow.Future) { remind = f.Get(ctx, nil) == nil })
+		}
+		if escalationTimer != nil {
+			selector.AddFuture(escalationTimer, func(f workflow.Future) { escalate = f.Get(ctx, nil) == nil })
+		}
+		if deadlineTimer != nil {
+			selector.AddFuture(deadlineTimer, func(f workflow.Future) { expired = f.Get(ctx, nil) == nil })
+		}
+		selector.Select(ctx)
+
+		if status := w.status(); len(status) > 0 {
+			return &Result{Status: status, Decisions: w.decisions}, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		switch {
+		case expired:
+			return &Result{Status: StatusExpired, Decisions: w.decisions}, nil
+		case escalate:
+			escalate, escalationTimer = false, nil
+			w.escalate()
+			w.notify(ctx, NotificationEscalated)
+		case remind:
+			remind = false
+			w.reminders++
+			w.notify(ctx, NotificationReminder)
+			reminderTimer = workflow.NewTimer(ctx, w.req.ReminderInterval)
+		}
+	}
+}
+
+// decide records a decision of an allowed approver
+func (w *waiter) decide(ctx workflow.Context, d Decision) {
+	logger := workflow.GetLogger(ctx)
+	if !w.allowed(d.Approver) {
+		logger.Warn(fmt.Sprintf("approval %s: ignored decision from %s - not an approver", w.req.ID, d.Approver))
+		return
+	}
+	if w.decided[d.Approver] {
+		logger.Warn(fmt.Sprintf("approval %s: ignored repeated decision from %s", w.req.ID, d.Approver))
+		return
+	}
+	if d.Time.IsZero() {
+		d.Time = workflow.Now(ctx)
+	}
+	w.decided[d.Approver] = true
+	w.decisions = append(w.decisions, d)
+	if d.Approve {
+		w.pending.Approvals++
+	}
+	w.updateWaiting()
+}
+
+func (w *waiter) status() string {
+	for _, d := range w.decisions {
+		if !d.Approve {
+			return StatusRejected
+		}
+	}
+	if w.pending.Approvals >= w.req.Quorum {
+		return StatusApproved
+	}
+	return ""
+}
+
+func (w *waiter) allowed(approver string) bool {
+	if len(approver) == 0 {
+		return false
+	}
+	if len(w.pending.Approvers) == 0 {
+		return true
+	}
+	for _, a := range w.pending.Approvers {
+		if a == approver {
+			return true
+		}
+	}
+	return false
+}
+
+// escalate adds the escalation approvers
+func (w *waiter) escalate() {
+	w.pending.Escalated = true
+	if len(w.pending.Approvers) > 0 {
+		for _, a := range w.req.EscalateTo {
+			if !w.allowed(a) {
+				w.pending.Approvers = append(w.pending.Approvers, a)
+			}
+		}
+	}
+	w.updateWaiting()
+}
+
+func (w *waiter) updateWaiting() {
+	w.pending.Waiting = nil
+	for _, a := range w.pending.Approvers {
+		if !w.decided[a] {
+			w.pending.Waiting = append(w.pending.Waiting, a)
+		}
+	}
+}
+
+// notify runs the notify activity - a failed notification is logged and does not fail the approval
+func (w *waiter) notify(ctx workflow.Context, kind string) {
+	if w.req.NotifyActivity == nil {
+		return
+	}
+	n := Notification{
+		Kind:       kind,
+		RequestID:  w.req.ID,
+		WorkflowID: w.info.WorkflowExecution.ID,
+		RunID:      w.info.WorkflowExecution.RunID,
+		Approvers:  w.pending.Approvers,
+		Waiting:    w.pending.Waiting,
+		Reminder:   w.reminders,
+		Details:    w.req.Details,
+	}
+	if kind == NotificationEscalated {
+		n.Waiting = w.req.EscalateTo
+	}
+	if err := workflow.ExecuteActivity(ctx, w.req.NotifyActivity, n).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error(fmt.Sprintf("approval %s: failed to send %s notification: %v", w.req.ID, kind, err))
+	}
+}
@@ -0,0 +1,134 @@
+package approval
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"time"
+)
+
+// Client sends decisions and reads pending approvals. Workflows are searched in the worker groups of the given task
+// lists (one task list per worker group is enough).
+type Client struct {
+	api       cadence.Api
+	taskLists []string
+}
+
+// NewClient creates a client for the worker groups of the task lists
+func NewClient(api cadence.Api, taskLists ...string) *Client {
+	return &Client{api: api, taskLists: taskLists}
+}
+
+// WorkflowApprovals are the pending approvals of a workflow
+type WorkflowApprovals struct {
+	TaskList   string    `json:"task_list"`
+	WorkflowID string    `json:"workflow_id"`
+	RunID      string    `json:"run_id"`
+	Pending    []Pending `json:"pending"`
+}
+
+// Approve sends an approval for the request
+func (c *Client) Approve(ctx context.Context, workflowID string, runID string, requestID string, approver string, comment string) error {
+	return c.decide(ctx, workflowID, runID, requestID, Decision{Approver: approver, Approve: true, Comment: comment})
+}
+
+// Reject sends a rejection for the request
+func (c *Client) Reject(ctx context.Context, workflowID string, runID string, requestID string, approver string, comment string) error {
+	return c.decide(ctx, workflowID, runID, requestID, Decision{Approver: approver, Approve: false, Comment: comment})
+}
+
+func (c *Client) decide(ctx context.Context, workflowID string, runID string, requestID string, d Decision) error {
+	if len(d.Approver) == 0 {
+		return errors.New("approver is required")
+	}
+	ctx, err := c.find(ctx, workflowID, runID)
+	if err != nil {
+		return err
+	}
+	d.Time = time.Now()
+	return c.api.SignalWorkflow(ctx, workflowID, runID, SignalName(requestID), d)
+}
+
+// Pending returns the pending approvals of a workflow
+func (c *Client) Pending(ctx context.Context, workflowID string, runID string) ([]Pending, error) {
+	ctx, err := c.find(ctx, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return c.query(ctx, workflowID, runID)
+}
+
+// ListPending returns the pending approvals of open workflows in all worker groups. query is a visibility query
+// which selects the workflows to check e.g. "WorkflowType = 'main.RefundWorkflow'"; at most limit workflows are
+// checked per worker group (0 checks all). Workflows which do not use approvals are skipped.
+func (c *Client) ListPending(ctx context.Context, query string, limit int) ([]WorkflowApprovals, error) {
+	if len(query) > 0 {
+		query = "CloseTime = missing AND " + query
+	} else {
+		query = "CloseTime = missing"
+	}
+
+	var result []WorkflowApprovals
+	for _, tl := range c.taskLists {
+		tlCtx := context.WithValue(ctx, cadence.TaskListForAction, tl)
+		var token []byte
+		checked := 0
+		for limit <= 0 || checked < limit {
+			pageSize := int32(100)
+			if limit > 0 {
+				pageSize = int32(min(limit-checked, 100))
+			}
+			resp, err := c.api.ListWorkflow(tlCtx, &shared.ListWorkflowExecutionsRequest{PageSize: &pageSize, NextPageToken: token, Query: &query})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to list workflows for task list %s", tl)
+			}
+			for _, e := range resp.GetExecutions() {
+				checked++
+				pending, err := c.query(tlCtx, e.GetExecution().GetWorkflowId(), e.GetExecution().GetRunId())
+				if err != nil || len(pending) == 0 {
+					continue
+				}
+				result = append(result, WorkflowApprovals{
+					TaskList:   tl,
+					WorkflowID: e.GetExecution().GetWorkflowId(),
+					RunID:      e.GetExecution().GetRunId(),
+					Pending:    pending,
+				})
+			}
+			token = resp.GetNextPageToken()
+			if len(token) == 0 {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) query(ctx context.Context, workflowID string, runID string) ([]Pending, error) {
+	value, err := c.api.QueryWorkflow(ctx, workflowID, runID, QueryPending)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Pending
+	if value != nil && value.HasValue() {
+		if err := value.Get(&pending); err != nil {
+			return nil, errors.Wrap(err, "failed to read pending approvals of workflow %s", workflowID)
+		}
+	}
+	return pending, nil
+}
+
+// find returns the context with the task list of the worker group which has the workflow
+func (c *Client) find(ctx context.Context, workflowID string, runID string) (context.Context, error) {
+	if tl, ok := ctx.Value(cadence.TaskListForAction).(string); ok && len(tl) > 0 {
+		return ctx, nil
+	}
+	for _, tl := range c.taskLists {
+		tlCtx := context.WithValue(ctx, cadence.TaskListForAction, tl)
+		if _, err := c.api.DescribeWorkflowExecution(tlCtx, workflowID, runID); err == nil {
+			return tlCtx, nil
+		}
+	}
+	return nil, errors.New("workflow %s not found in the worker groups of task lists %v", workflowID, c.taskLists)
+}
@@ -0,0 +1,152 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/.gen/go/shared"
+	"os"
+	"os/user"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// BuildIDEnvName is the env variable used to find the build id if it is not set in the config or with ldflags
+const BuildIDEnvName = "GOX_WORKFLOW_BUILD_ID"
+
+// BuildID can be set at build time:
+//
+//	go build -ldflags "-X github.com/devlibx/gox-workfkow/workflow/framework/cadence.BuildID=v1.4.2"
+var BuildID string
+
+// BadBinary is a binary checksum (build id) marked as bad on a domain. Workers of a bad binary do not get decision
+// tasks of the domain.
+type BadBinary struct {
+	Domain      string    `json:"domain"`
+	Checksum    string    `json:"checksum"`
+	Reason      string    `json:"reason"`
+	Operator    string    `json:"operator"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+// WithBuildID sets the build id - it takes precedence over the config, ldflags and env
+func WithBuildID(buildID string) Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		wrapper.buildID = buildID
+	}
+}
+
+// ResolveBuildID returns the build id used as the binary checksum of the workers. The first one set is used:
+// configured build id (WithBuildID or build_id in config), BuildID set with ldflags, GOX_WORKFLOW_BUILD_ID env,
+// VCS revision from the build info (with "-dirty" suffix if modified) and the version of the main module.
+// An empty build id keeps the cadence default (md5 of the executable).
+func ResolveBuildID(configured string) string {
+	if len(configured) > 0 {
+		return configured
+	}
+	if len(BuildID) > 0 {
+		return BuildID
+	}
+	if env := os.Getenv(BuildIDEnvName); len(env) > 0 {
+		return env
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	revision, modified := "", false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if len(revision) > 0 {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if modified {
+			revision += "-dirty"
+		}
+		return revision
+	}
+	if v := info.Main.Version; len(v) > 0 && v != "(devel)" {
+		return v
+	}
+	return ""
+}
+
+func (wrapper *cadenceWrapperImpl) MarkBadBinary(ctx context.Context, domain string, checksum string, reason string) error {
+	cadenceWorkerObj, err := wrapper.workerGroupForDomain(domain)
+	if err != nil {
+		return err
+	}
+	if len(checksum) == 0 {
+		return errors.New("binary checksum is required")
+	}
+
+	operator := ""
+	if u, err := user.Current(); err == nil {
+		operator = u.Username
+	}
+	err = cadenceWorkerObj.cadenceDomainClient.Update(ctx, &shared.UpdateDomainRequest{
+		Name: &domain,
+		Configuration: &shared.DomainConfiguration{
+			BadBinaries: &shared.BadBinaries{Binaries: map[string]*shared.BadBinaryInfo{
+				checksum: {Reason: &reason, Operator: &operator},
+			}},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to mark bad binary - domain=%s, checksum=%s", domain, checksum)
+	}
+	return nil
+}
+
+func (wrapper *cadenceWrapperImpl) RemoveBadBinary(ctx context.Context, domain string, checksum string) error {
+	cadenceWorkerObj, err := wrapper.workerGroupForDomain(domain)
+	if err != nil {
+		return err
+	}
+	if err = cadenceWorkerObj.cadenceDomainClient.Update(ctx, &shared.UpdateDomainRequest{Name: &domain, DeleteBadBinary: &checksum}); err != nil {
+		return errors.Wrap(err, "failed to remove bad binary - domain=%s, checksum=%s", domain, checksum)
+	}
+	return nil
+}
+
+func (wrapper *cadenceWrapperImpl) ListBadBinaries(ctx context.Context, domain string) ([]BadBinary, error) {
+	cadenceWorkerObj, err := wrapper.workerGroupForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cadenceWorkerObj.cadenceDomainClient.Describe(ctx, domain)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe domain=%s", domain)
+	}
+
+	result := make([]BadBinary, 0)
+	for checksum, info := range resp.GetConfiguration().GetBadBinaries().GetBinaries() {
+		result = append(result, BadBinary{
+			Domain:      domain,
+			Checksum:    checksum,
+			Reason:      info.GetReason(),
+			Operator:    info.GetOperator(),
+			CreatedTime: time.Unix(0, info.GetCreatedTimeNano()),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedTime.Before(result[j].CreatedTime) })
+	return result, nil
+}
+
+// workerGroupForDomain returns the (first) worker group of the domain
+func (wrapper *cadenceWrapperImpl) workerGroupForDomain(domain string) (*cadenceWorker, error) {
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.workerGroup.Domain == domain {
+			return cadenceWorkerObj, nil
+		}
+	}
+	return nil, errors.New("domain not used by any worker group in application config: %s", domain)
+}
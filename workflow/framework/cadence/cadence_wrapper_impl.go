@@ -4,20 +4,47 @@ import (
 	"context"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/client"
 	"go.uber.org/cadence/encoded"
+	"go.uber.org/cadence/worker"
 	"go.uber.org/cadence/workflow"
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 	"log/slog"
+	"slices"
 	"sync"
 )
 
 type cadenceWrapperImpl struct {
 	gox.CrossFunction
-	config       *Config
+	config *Config
+
+	// workerGroups are replaced (not modified) when a worker group is started or stopped by a config reload
+	groupsMu     sync.RWMutex
 	workerGroups []*cadenceWorker
-	zapLogger    *zap.Logger
+
+	// detectors are the active cluster detectors of the worker groups with clusters (guarded by groupsMu)
+	detectors map[string]*clusterDetector
+
+	logging     *logging
+	slogHandler slog.Handler
+
+	interceptors         WorkerInterceptors
+	taskListInterceptors map[string]WorkerInterceptors
+
+	// clientOnly if true will not start the pollers (and shadowers) - only the clients are created
+	clientOnly bool
+
+	// buildID is set with WithBuildID - see ResolveBuildID
+	buildID string
+
+	// controlMu serializes AddWorker, RemoveWorker, PauseWorker, ResumeWorker, ReloadConfig, failover and Shutdown
+	controlMu sync.Mutex
+
+	// closed is set by Shutdown (guarded by controlMu) - the control operations fail after it
+	closed bool
+
+	// configSource is watched for config changes - see WithConfigSource
+	configSource ConfigSource
 
 	shoutDownOnce *sync.Once
 }
@@ -34,45 +61,121 @@ func (wrapper *cadenceWrapperImpl) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Wrapper logs and cadence logs are written to the same sink
+	var err error
+	if wrapper.logging, err = newLogging(wrapper.config.Logging, wrapper.CrossFunction, wrapper.slogHandler, wrapper.config.EnableErrorStackInCadenceLog); err != nil {
+		return errors.Wrap(err, "failed to create logger for cadence")
+	}
+
 	if wrapper.config.Disabled {
-		slog.Warn("cadence is disabled - will not start any worker")
+		wrapper.logging.slogger.Warn("cadence is disabled - will not start any worker")
 		return nil
 	}
 
-	// The errors in the cadence logs are not very helpful. So we are disabling ing stack trace
-	if !wrapper.config.EnableErrorStackInCadenceLog && wrapper.zapLogger == nil {
-		var e error
-		c := zap.NewProductionConfig()
-		c.EncoderConfig.StacktraceKey = ""
-		c.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-		if wrapper.zapLogger, e = c.Build(); e != nil {
-			return errors.Wrap(e, "failed to create zap logger - stack trace is disabled in cadence log")
-		}
+	// The binary checksum is global in the cadence client - it must be set before any worker starts
+	if len(wrapper.buildID) == 0 {
+		wrapper.buildID = wrapper.config.BuildID
+	}
+	if wrapper.buildID = ResolveBuildID(wrapper.buildID); len(wrapper.buildID) > 0 {
+		worker.SetBinaryChecksum(wrapper.buildID)
+		wrapper.logging.slogger.Info("cadence worker build id", slog.String("buildId", wrapper.buildID))
 	}
 
-	wrapper.workerGroups = make([]*cadenceWorker, 0)
 	for name, wg := range wrapper.config.WorkerGroups {
 		wg.Name = name
 
 		if wg.Disabled {
-			slog.Warn("cadence worker group is disabled - worker group = %s", wg.Name)
-		} else {
-			worker := &cadenceWorker{
-				CrossFunction:        wrapper.CrossFunction,
-				createDispatcherOnce: &sync.Once{},
-				workerGroup:          &wg,
-				logger:               wrapper.zapLogger,
-			}
-			if err := worker.Start(ctx); err != nil {
-				return errors.Wrap(err, "failed to start cadence worker group - worker group = %s", wg.Name)
-			}
-			wrapper.workerGroups = append(wrapper.workerGroups, worker)
+			wrapper.logging.slogger.Warn("cadence worker group is disabled", slog.String("workerGroup", wg.Name))
+		} else if err := wrapper.startWorkerGroup(ctx, wg); err != nil {
+			return err
 		}
 	}
 
+	if wrapper.configSource != nil {
+		go wrapper.watchConfig(ctx)
+	}
+
 	return nil
 }
 
+// startWorkerGroup starts the worker group and adds it to the routing. A worker group with clusters is started in
+// the active cluster of the domain and a detector moves it to the new active cluster on a failover.
+func (wrapper *cadenceWrapperImpl) startWorkerGroup(ctx context.Context, wg WorkerGroup) error {
+	var detector *clusterDetector
+	cluster := ""
+	if len(wg.Clusters) > 0 {
+		var err error
+		if detector, err = wrapper.newClusterDetector(wg); err != nil {
+			return err
+		}
+		active, err := detector.activeCluster(ctx)
+		if err != nil {
+			detector.stop()
+			return errors.Wrap(err, "failed to find active cluster - worker group = %s", wg.Name)
+		}
+		wg.HostPort, cluster = active.HostPort, active.Name
+	}
+
+	configureCircuitBreaker(wg.Name, wrapper.config.Client.CircuitBreaker)
+	cadenceWorkerObj := wrapper.newCadenceWorker(wg, cluster)
+	if err := cadenceWorkerObj.Start(ctx); err != nil {
+		if detector != nil {
+			detector.stop()
+		}
+		return errors.Wrap(err, "failed to start cadence worker group - worker group = %s", wg.Name)
+	}
+
+	wrapper.groupsMu.Lock()
+	defer wrapper.groupsMu.Unlock()
+	wrapper.workerGroups = append(slices.Clone(wrapper.workerGroups), cadenceWorkerObj)
+	if detector != nil {
+		if wrapper.detectors == nil {
+			wrapper.detectors = map[string]*clusterDetector{}
+		}
+		wrapper.detectors[wg.Name] = detector
+		detector.start()
+	}
+	return nil
+}
+
+// newCadenceWorker creates (but does not start) the worker group
+func (wrapper *cadenceWrapperImpl) newCadenceWorker(wg WorkerGroup, cluster string) *cadenceWorker {
+	slogger := wrapper.logging.slogger.With(slog.String("workerGroup", wg.Name))
+	if len(cluster) > 0 {
+		slogger = slogger.With(slog.String("cluster", cluster))
+	}
+	return &cadenceWorker{
+		CrossFunction:        wrapper.CrossFunction,
+		createDispatcherOnce: &sync.Once{},
+		workerGroup:          &wg,
+		logger:               wrapper.logging.zapLoggerForTaskList,
+		slogger:              slogger,
+		interceptors:         wrapper.interceptorsForTaskList,
+		clientOnly:           wrapper.clientOnly,
+		buildID:              wrapper.buildID,
+		cluster:              cluster,
+		client:               &wrapper.config.Client,
+	}
+}
+
+// stopDetector stops the active cluster detector of the worker group (if it has clusters)
+func (wrapper *cadenceWrapperImpl) stopDetector(group string) {
+	wrapper.groupsMu.Lock()
+	detector := wrapper.detectors[group]
+	delete(wrapper.detectors, group)
+	wrapper.groupsMu.Unlock()
+	if detector != nil {
+		detector.stop()
+	}
+}
+
+// groups returns the running worker groups - the returned slice is never modified
+func (wrapper *cadenceWrapperImpl) groups() []*cadenceWorker {
+	wrapper.groupsMu.RLock()
+	defer wrapper.groupsMu.RUnlock()
+	return wrapper.workerGroups
+}
+
 func (wrapper *cadenceWrapperImpl) Shutdown(ctx context.Context) (chan error, error) {
 	doneCh := make(chan error, 2)
 	defer func() {
@@ -81,7 +184,15 @@ func (wrapper *cadenceWrapperImpl) Shutdown(ctx context.Context) (chan error, er
 	}()
 
 	wrapper.shoutDownOnce.Do(func() {
-		for _, cadenceWorkerObj := range wrapper.workerGroups {
+		for _, cadenceWorkerObj := range wrapper.groups() {
+			wrapper.stopDetector(cadenceWorkerObj.workerGroup.Name)
+		}
+
+		// Waits for a running control operation (e.g. a failover) - the worker groups are not changed after this
+		wrapper.controlMu.Lock()
+		defer wrapper.controlMu.Unlock()
+		wrapper.closed = true
+		for _, cadenceWorkerObj := range wrapper.groups() {
 			ch := make(chan error, 2)
 			if err := cadenceWorkerObj.Shutdown(ctx, ch); err == nil {
 				<-ch
@@ -92,19 +203,31 @@ func (wrapper *cadenceWrapperImpl) Shutdown(ctx context.Context) (chan error, er
 	return doneCh, nil
 }
 
+// checkNotClosed returns an error if the client is shut down - it must be called with controlMu held
+func (wrapper *cadenceWrapperImpl) checkNotClosed() error {
+	if wrapper.closed {
+		return errors.New("cadence client is shut down")
+	}
+	return nil
+}
+
 func (wrapper *cadenceWrapperImpl) StartWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflowFunc interface{}, args ...interface{}) (*workflow.Execution, error) {
-	for _, cadenceWorkerObj := range wrapper.workerGroups {
-		if _, ok := cadenceWorkerObj.cadenceWorkers[options.TaskList]; ok {
-			return cadenceWorkerObj.cadenceClient.StartWorkflow(ctx, options, workflowFunc, args...)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(options.TaskList) {
+			return callWithResult(ctx, cadenceWorkerObj, OperationStartWorkflow, func(ctx context.Context) (*workflow.Execution, error) {
+				return cadenceWorkerObj.cadenceClient.StartWorkflow(ctx, options, workflowFunc, args...)
+			})
 		}
 	}
 	return nil, errors.New(`task list not registered in application config to run this workflow: %s`, options.TaskList)
 }
 
 func (wrapper *cadenceWrapperImpl) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
-	for _, cadenceWorkerObj := range wrapper.workerGroups {
-		if _, ok := cadenceWorkerObj.cadenceWorkers[options.TaskList]; ok {
-			return cadenceWorkerObj.cadenceClient.ExecuteWorkflow(ctx, options, workflow, args...)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(options.TaskList) {
+			return callWithResult(ctx, cadenceWorkerObj, OperationExecuteWorkflow, func(ctx context.Context) (client.WorkflowRun, error) {
+				return cadenceWorkerObj.cadenceClient.ExecuteWorkflow(ctx, options, workflow, args...)
+			})
 		}
 	}
 	return nil, errors.New("task list not registered in application config to run this workflow: %s", options.TaskList)
@@ -116,9 +239,11 @@ func (wrapper *cadenceWrapperImpl) CancelWorkflow(ctx context.Context, workflowI
 		return err
 	}
 
-	for _, cadenceWorkerObj := range wrapper.workerGroups {
-		if _, ok := cadenceWorkerObj.cadenceWorkers[taskList]; ok {
-			return cadenceWorkerObj.cadenceClient.CancelWorkflow(ctx, workflowID, runID)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return cadenceWorkerObj.call(ctx, OperationCancelWorkflow, func(ctx context.Context) error {
+				return cadenceWorkerObj.cadenceClient.CancelWorkflow(ctx, workflowID, runID)
+			})
 		}
 	}
 	return errors.New("task list not registered in application config to run this workflow: %s", taskList)
@@ -130,28 +255,144 @@ func (wrapper *cadenceWrapperImpl) QueryWorkflow(ctx context.Context, workflowID
 		return nil, err
 	}
 
-	for _, cadenceWorkerObj := range wrapper.workerGroups {
-		if _, ok := cadenceWorkerObj.cadenceWorkers[taskList]; ok {
-			return cadenceWorkerObj.cadenceClient.QueryWorkflow(ctx, workflowID, runID, queryType, args...)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return callWithResult(ctx, cadenceWorkerObj, OperationQueryWorkflow, func(ctx context.Context) (encoded.Value, error) {
+				return cadenceWorkerObj.cadenceClient.QueryWorkflow(ctx, workflowID, runID, queryType, args...)
+			})
+		}
+	}
+	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
+func (wrapper *cadenceWrapperImpl) GetWorkflow(ctx context.Context, workflowID string, runID string) (client.WorkflowRun, error) {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return cadenceWorkerObj.cadenceClient.GetWorkflow(ctx, workflowID, runID), nil
 		}
 	}
 	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
 }
 
+func (wrapper *cadenceWrapperImpl) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return cadenceWorkerObj.call(ctx, OperationSignalWorkflow, func(ctx context.Context) error {
+				return cadenceWorkerObj.cadenceClient.SignalWorkflow(ctx, workflowID, runID, signalName, arg)
+			})
+		}
+	}
+	return errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
 func (wrapper *cadenceWrapperImpl) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error {
 	taskList, err := wrapper.getTaskListFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	for _, cadenceWorkerObj := range wrapper.workerGroups {
-		if _, ok := cadenceWorkerObj.cadenceWorkers[taskList]; ok {
-			return cadenceWorkerObj.cadenceClient.TerminateWorkflow(ctx, workflowID, runID, reason, details)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return cadenceWorkerObj.call(ctx, OperationTerminateWorkflow, func(ctx context.Context) error {
+				return cadenceWorkerObj.cadenceClient.TerminateWorkflow(ctx, workflowID, runID, reason, details)
+			})
 		}
 	}
 	return errors.New("task list not registered in application config to run this workflow: %s", taskList)
 }
 
+func (wrapper *cadenceWrapperImpl) GetWorkflowHistory(ctx context.Context, workflowID string, runID string) (*shared.History, error) {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			// A retry reads the history from the start
+			return callWithResult(ctx, cadenceWorkerObj, OperationGetWorkflowHistory, func(ctx context.Context) (*shared.History, error) {
+				history := &shared.History{}
+				iter := cadenceWorkerObj.cadenceClient.GetWorkflowHistory(ctx, workflowID, runID, false, shared.HistoryEventFilterTypeAllEvent)
+				for iter.HasNext() {
+					event, err := iter.Next()
+					if err != nil {
+						return nil, errors.Wrap(err, "failed to read workflow history - workflowID=%s runID=%s", workflowID, runID)
+					}
+					history.Events = append(history.Events, event)
+				}
+				return history, nil
+			})
+		}
+	}
+	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
+func (wrapper *cadenceWrapperImpl) ListWorkflow(ctx context.Context, request *shared.ListWorkflowExecutionsRequest) (*shared.ListWorkflowExecutionsResponse, error) {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			if request.Domain == nil || len(*request.Domain) == 0 {
+				domain := cadenceWorkerObj.workerGroup.Domain
+				request.Domain = &domain
+			}
+			return callWithResult(ctx, cadenceWorkerObj, OperationListWorkflow, func(ctx context.Context) (*shared.ListWorkflowExecutionsResponse, error) {
+				return cadenceWorkerObj.cadenceClient.ListWorkflow(ctx, request)
+			})
+		}
+	}
+	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
+func (wrapper *cadenceWrapperImpl) DescribeWorkflowExecution(ctx context.Context, workflowID string, runID string) (*shared.DescribeWorkflowExecutionResponse, error) {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return callWithResult(ctx, cadenceWorkerObj, OperationDescribeWorkflowExecution, func(ctx context.Context) (*shared.DescribeWorkflowExecutionResponse, error) {
+				return cadenceWorkerObj.cadenceClient.DescribeWorkflowExecution(ctx, workflowID, runID)
+			})
+		}
+	}
+	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
+func (wrapper *cadenceWrapperImpl) ResetWorkflow(ctx context.Context, request *shared.ResetWorkflowExecutionRequest) (*shared.ResetWorkflowExecutionResponse, error) {
+	taskList, err := wrapper.getTaskListFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			if request.Domain == nil || len(*request.Domain) == 0 {
+				domain := cadenceWorkerObj.workerGroup.Domain
+				request.Domain = &domain
+			}
+			return callWithResult(ctx, cadenceWorkerObj, OperationResetWorkflow, func(ctx context.Context) (*shared.ResetWorkflowExecutionResponse, error) {
+				return cadenceWorkerObj.cadenceClient.ResetWorkflow(ctx, request)
+			})
+		}
+	}
+	return nil, errors.New("task list not registered in application config to run this workflow: %s", taskList)
+}
+
 func (wrapper *cadenceWrapperImpl) getTaskListFromContext(ctx context.Context) (string, error) {
 	if ctx.Value(TaskListForAction) == nil {
 		return "", errors.New("please set task list name in context parameter - set task list name with key: %s", TaskListForAction)
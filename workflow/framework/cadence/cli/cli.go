@@ -0,0 +1,278 @@
+// Package cli implements the gox-workflow command line tool. It reads the same cadence.Config YAML used by the
+// application (with env variables expanded) and routes every command to the worker group which owns the task list,
+// so the user does not need to know which cadence host serves which domain.
+//
+//	gox-workflow -config config.yaml start -task-list server_1_ts_1 -type main.OrderWorkflow -input '{"id": "1"}'
+//	gox-workflow -config config.yaml -o table describe -id order-1
+//	gox-workflow -config config.yaml list -group worker_group_1 -query "CloseTime = missing"
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-base/v2/serialization"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigEnvName is the env variable used to find the config file if -config is not given
+	ConfigEnvName = "GOX_WORKFLOW_CONFIG"
+
+	outputJson  = "json"
+	outputTable = "table"
+)
+
+// errUsage is returned by a command if the flags are not valid - the usage is already printed
+var errUsage = errors.New("usage")
+
+// command is a sub command of the cli
+type command struct {
+	name  string
+	usage string
+	run   func(app *app, args []string) error
+}
+
+var commands = []*command{
+	{name: "start", usage: "start a workflow", run: runStart},
+	{name: "signal", usage: "send a signal to a workflow", run: runSignal},
+	{name: "query", usage: "query a workflow", run: runQuery},
+	{name: "cancel", usage: "cancel a workflow", run: runCancel},
+	{name: "terminate", usage: "terminate a workflow", run: runTerminate},
+	{name: "describe", usage: "describe a workflow", run: runDescribe},
+	{name: "list", usage: "list workflows using a visibility query", run: runList},
+	{name: "history", usage: "history dump - write the history of a workflow as JSON", run: runHistory},
+	{name: "reset", usage: "reset a workflow to a decision", run: runReset},
+	{name: "batch", usage: "signal, cancel or terminate all workflows matching a visibility query", run: runBatch},
+	{name: "bad-binary", usage: "mark, remove or list bad binary checksums (build ids) of a domain", run: runBadBinary},
+	{name: "versions", usage: "report the GetVersion change versions used by open workflows", run: runVersions},
+	{name: "config", usage: "config lint - validate the config file and print all the problems found", run: runConfig},
+}
+
+// app is the state shared by all commands
+type app struct {
+	configFile string
+	verbose    bool
+
+	config  *cadence.Config
+	api     cadence.Api
+	ctx     context.Context
+	out     *printer
+	stdout  io.Writer
+	stderr  io.Writer
+	timeout time.Duration
+}
+
+// Run runs the cli with the given args (without the program name) and returns the exit code
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gox-workflow", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", os.Getenv(ConfigEnvName), "cadence config YAML file - env variables are expanded (default $"+ConfigEnvName+")")
+	output := fs.String("o", outputJson, "output format - json or table")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for the command")
+	verbose := fs.Bool("v", false, "print cadence client logs")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "usage: gox-workflow [flags] <command> [command flags]\n\nflags:\n")
+		fs.PrintDefaults()
+		_, _ = fmt.Fprintf(stderr, "\ncommands:\n")
+		for _, c := range commands {
+			_, _ = fmt.Fprintf(stderr, "  %-10s %s\n", c.name, c.usage)
+		}
+		_, _ = fmt.Fprintf(stderr, "\nuse \"gox-workflow <command> -h\" for the command flags\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *output != outputJson && *output != outputTable {
+		_, _ = fmt.Fprintf(stderr, "gox-workflow: output must be %s or %s\n", outputJson, outputTable)
+		return 2
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == fs.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		_, _ = fmt.Fprintf(stderr, "gox-workflow: unknown command %s\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := &app{
+		configFile: *configFile,
+		verbose:    *verbose,
+		ctx:        ctx,
+		out:        &printer{w: stdout, format: *output},
+		stdout:     stdout,
+		stderr:     stderr,
+		timeout:    *timeout,
+	}
+	defer func() {
+		if a.api == nil {
+			return
+		}
+		if ch, err := a.api.Shutdown(ctx); err == nil {
+			<-ch
+		}
+	}()
+
+	if err := cmd.run(a, fs.Args()[1:]); err != nil {
+		if err == errUsage {
+			return 2
+		}
+		_, _ = fmt.Fprintf(stderr, "gox-workflow: %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+// LoadConfig reads the cadence config YAML file and expands the env variables
+func LoadConfig(file string) (*cadence.Config, error) {
+	if len(file) == 0 {
+		return nil, errors.New("config file is not set - use -config or set %s", ConfigEnvName)
+	}
+	c := &cadence.Config{}
+	if err := serialization.ReadYamlWithEnvVar(file, c); err != nil {
+		return nil, errors.Wrap(err, "failed to read config file %s", file)
+	}
+	return c, nil
+}
+
+// connect creates a client only cadence api (no workers are started)
+func (a *app) connect() error {
+	var err error
+	if a.config, err = LoadConfig(a.configFile); err != nil {
+		return err
+	}
+
+	level := slog.LevelError
+	if a.verbose {
+		level = slog.LevelDebug
+	} else {
+		a.config.Logging.Level = "error"
+	}
+	handler := slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: level})
+
+	if a.api, err = cadence.NewCadenceClient(gox.NewNoOpCrossFunction(), a.config, cadence.WithClientOnly(), cadence.WithSlogHandler(handler)); err != nil {
+		return errors.Wrap(err, "failed to create cadence client")
+	}
+	if err = a.api.Start(a.ctx); err != nil {
+		a.api = nil
+		return errors.Wrap(err, "failed to start cadence client")
+	}
+	return nil
+}
+
+// context returns a context with timeout and the task list set for the action
+func (a *app) context(taskList string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(a.ctx, a.timeout)
+	return context.WithValue(ctx, cadence.TaskListForAction, taskList), cancel
+}
+
+// groupNames returns the names of enabled worker groups (with an enabled worker) in sorted order
+func (a *app) groupNames() []string {
+	var names []string
+	for name, wg := range a.config.WorkerGroups {
+		if !wg.Disabled && len(enabledTaskList(wg)) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// groupTaskList returns a task list of the worker group - it is used to route the calls to the worker group
+func (a *app) groupTaskList(group string) (string, error) {
+	wg, ok := a.config.WorkerGroups[group]
+	if !ok || wg.Disabled || len(enabledTaskList(wg)) == 0 {
+		return "", errors.New("worker group %s not found (or disabled) - available worker groups: %s", group, strings.Join(a.groupNames(), ", "))
+	}
+	return enabledTaskList(wg), nil
+}
+
+// enabledTaskList returns the task list of the first enabled worker - disabled workers are not registered for routing
+func enabledTaskList(wg cadence.WorkerGroup) string {
+	for _, w := range wg.Workers {
+		if !w.Disabled {
+			return w.TaskList
+		}
+	}
+	return ""
+}
+
+// route is the common routing flags of the commands
+type route struct {
+	taskList string
+	group    string
+}
+
+func (r *route) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.taskList, "task-list", "", "task list used to find the worker group")
+	fs.StringVar(&r.group, "group", "", "worker group name (used if task-list is not given)")
+}
+
+// taskLists returns the task lists to use for the command - one per worker group. If neither task list nor worker
+// group is given then all worker groups are returned.
+func (a *app) taskLists(r route) ([]string, error) {
+	if len(r.taskList) > 0 {
+		return []string{r.taskList}, nil
+	}
+	if len(r.group) > 0 {
+		tl, err := a.groupTaskList(r.group)
+		if err != nil {
+			return nil, err
+		}
+		return []string{tl}, nil
+	}
+	var out []string
+	for _, name := range a.groupNames() {
+		tl, _ := a.groupTaskList(name)
+		out = append(out, tl)
+	}
+	return out, nil
+}
+
+// findWorkflow returns the task list of the worker group which has the workflow. If task list or worker group is not
+// given then all worker groups are checked with describe workflow.
+func (a *app) findWorkflow(r route, workflowID string, runID string) (string, error) {
+	taskLists, err := a.taskLists(r)
+	if err != nil {
+		return "", err
+	}
+	if len(taskLists) == 1 {
+		return taskLists[0], nil
+	}
+
+	for _, tl := range taskLists {
+		ctx, cancel := a.context(tl)
+		_, err := a.api.DescribeWorkflowExecution(ctx, workflowID, runID)
+		cancel()
+		if err == nil {
+			return tl, nil
+		}
+
+		// Only a missing workflow means the workflow is in another worker group
+		var notExists *shared.EntityNotExistsError
+		if !errors.As(err, &notExists) {
+			return "", errors.Wrap(err, "failed to describe workflow %s in the worker group of task list %s", workflowID, tl)
+		}
+	}
+	return "", errors.New("workflow %s not found in any worker group - use -group or -task-list", workflowID)
+}
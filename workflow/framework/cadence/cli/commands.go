@@ -0,0 +1,675 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence/versioning"
+	"github.com/google/uuid"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"os"
+	"strings"
+	"time"
+)
+
+// inputs is a repeatable flag with JSON values - each value is passed as one argument to the workflow/signal/query
+type inputs []json.RawMessage
+
+func (i *inputs) String() string {
+	return fmt.Sprint(len(*i))
+}
+
+func (i *inputs) Set(value string) error {
+	if !json.Valid([]byte(value)) {
+		return errors.New("input must be a valid JSON - found %s", value)
+	}
+	*i = append(*i, json.RawMessage(value))
+	return nil
+}
+
+func (i inputs) args() []interface{} {
+	args := make([]interface{}, 0, len(i))
+	for _, in := range i {
+		args = append(args, in)
+	}
+	return args
+}
+
+func newFlagSet(a *app, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+// parse parses the command flags, makes sure the required flags are set and connects to cadence
+func (a *app) parse(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	for _, name := range required {
+		if f := fs.Lookup(name); f == nil || len(f.Value.String()) == 0 {
+			_, _ = fmt.Fprintf(fs.Output(), "flag -%s is required\n", name)
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return a.connect()
+}
+
+// groupOfTaskList returns the worker group name which has the task list
+func (a *app) groupOfTaskList(taskList string) string {
+	for name, wg := range a.config.WorkerGroups {
+		for _, w := range wg.Workers {
+			if w.TaskList == taskList {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func runStart(a *app, args []string) error {
+	fs := newFlagSet(a, "start")
+	var in inputs
+	taskList := fs.String("task-list", "", "task list of the workflow (required)")
+	workflowType := fs.String("type", "", "workflow type name (required)")
+	workflowID := fs.String("id", "", "workflow id (default random uuid)")
+	executionTimeout := fs.Duration("execution-timeout", time.Hour, "workflow execution timeout")
+	decisionTimeout := fs.Duration("decision-timeout", 10*time.Second, "decision task timeout")
+	cron := fs.String("cron", "", "cron schedule")
+	wait := fs.Bool("wait", false, "wait for the workflow to complete and print the result")
+	fs.Var(&in, "input", "workflow input as JSON (repeat for more arguments)")
+	if err := a.parse(fs, args, "task-list", "type"); err != nil {
+		return err
+	}
+	if len(*workflowID) == 0 {
+		*workflowID = uuid.NewString()
+	}
+
+	options := client.StartWorkflowOptions{
+		ID:                              *workflowID,
+		TaskList:                        *taskList,
+		ExecutionStartToCloseTimeout:    *executionTimeout,
+		DecisionTaskStartToCloseTimeout: *decisionTimeout,
+		CronSchedule:                    *cron,
+	}
+
+	ctx, cancel := a.context(*taskList)
+	defer cancel()
+	if !*wait {
+		execution, err := a.api.StartWorkflow(ctx, options, *workflowType, in.args()...)
+		if err != nil {
+			return err
+		}
+		t := &table{headers: []string{"WORKFLOW ID", "RUN ID"}}
+		t.add(execution.ID, execution.RunID)
+		return a.out.print(map[string]string{"workflow_id": execution.ID, "run_id": execution.RunID}, t)
+	}
+
+	run, err := a.api.ExecuteWorkflow(ctx, options, *workflowType, in.args()...)
+	if err != nil {
+		return err
+	}
+	var result interface{}
+	err = run.Get(ctx, &result)
+	out := map[string]interface{}{"workflow_id": run.GetID(), "run_id": run.GetRunID(), "result": result}
+	if err != nil {
+		out["error"] = err.Error()
+	}
+	t := &table{headers: []string{"WORKFLOW ID", "RUN ID", "RESULT", "ERROR"}}
+	t.add(run.GetID(), run.GetRunID(), toJson(result), errorString(err))
+	return a.out.print(out, t)
+}
+
+func runSignal(a *app, args []string) error {
+	fs := newFlagSet(a, "signal")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	name := fs.String("name", "", "signal name (required)")
+	input := fs.String("input", "", "signal input as JSON")
+	if err := a.parse(fs, args, "id", "name"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+	var arg interface{}
+	if len(*input) > 0 {
+		arg = json.RawMessage(*input)
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	if err = a.api.SignalWorkflow(ctx, *workflowID, *runID, *name, arg); err != nil {
+		return err
+	}
+	return a.printDone("signaled", *workflowID, *runID)
+}
+
+func runQuery(a *app, args []string) error {
+	fs := newFlagSet(a, "query")
+	var r route
+	var in inputs
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	queryType := fs.String("type", "", "query type (required) - use __stack_trace to get the stack trace")
+	fs.Var(&in, "input", "query argument as JSON (repeat for more arguments)")
+	if err := a.parse(fs, args, "id", "type"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	value, err := a.api.QueryWorkflow(ctx, *workflowID, *runID, *queryType, in.args()...)
+	if err != nil {
+		return err
+	}
+	var result interface{}
+	if value != nil && value.HasValue() {
+		if err = value.Get(&result); err != nil {
+			return errors.Wrap(err, "failed to decode query result")
+		}
+	}
+	t := &table{headers: []string{"RESULT"}}
+	t.add(toJson(result))
+	return a.out.print(result, t)
+}
+
+func runCancel(a *app, args []string) error {
+	fs := newFlagSet(a, "cancel")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	if err := a.parse(fs, args, "id"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	if err = a.api.CancelWorkflow(ctx, *workflowID, *runID); err != nil {
+		return err
+	}
+	return a.printDone("canceled", *workflowID, *runID)
+}
+
+func runTerminate(a *app, args []string) error {
+	fs := newFlagSet(a, "terminate")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	reason := fs.String("reason", "terminated by gox-workflow cli", "reason to terminate")
+	if err := a.parse(fs, args, "id"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	if err = a.api.TerminateWorkflow(ctx, *workflowID, *runID, *reason, nil); err != nil {
+		return err
+	}
+	return a.printDone("terminated", *workflowID, *runID)
+}
+
+func runDescribe(a *app, args []string) error {
+	fs := newFlagSet(a, "describe")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	if err := a.parse(fs, args, "id"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	response, err := a.api.DescribeWorkflowExecution(ctx, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	info := response.GetWorkflowExecutionInfo()
+	t := &table{headers: []string{"FIELD", "VALUE"}}
+	t.add("Group", a.groupOfTaskList(taskList))
+	t.add("WorkflowID", info.GetExecution().GetWorkflowId())
+	t.add("RunID", info.GetExecution().GetRunId())
+	t.add("Type", info.GetType().GetName())
+	t.add("TaskList", response.GetExecutionConfiguration().GetTaskList().GetName())
+	t.add("Status", executionStatus(info))
+	t.add("StartTime", formatTime(info.StartTime))
+	t.add("CloseTime", formatTime(info.CloseTime))
+	t.add("HistoryLength", fmt.Sprint(info.GetHistoryLength()))
+	t.add("PendingActivities", fmt.Sprint(len(response.PendingActivities)))
+	t.add("PendingChildren", fmt.Sprint(len(response.PendingChildren)))
+	return a.out.print(response, t)
+}
+
+func runList(a *app, args []string) error {
+	fs := newFlagSet(a, "list")
+	var r route
+	r.register(fs)
+	query := fs.String("query", "", "visibility query e.g. \"WorkflowType = 'x' AND CloseTime = missing\" (default all workflows)")
+	limit := fs.Int("limit", 100, "max workflows to list from each worker group (0 for all)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	taskLists, err := a.taskLists(r)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]executionRow, 0)
+	t := &table{headers: executionHeaders}
+	for _, tl := range taskLists {
+		group := a.groupOfTaskList(tl)
+		err := a.listWorkflows(tl, *query, *limit, func(info *shared.WorkflowExecutionInfo) error {
+			row := newExecutionRow(group, info)
+			rows = append(rows, row)
+			t.add(row.values()...)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list workflows in worker group %s", group)
+		}
+	}
+	return a.out.print(rows, t)
+}
+
+// listWorkflows calls fn for all the workflows (at most limit if limit > 0) matching the query
+func (a *app) listWorkflows(taskList string, query string, limit int, fn func(info *shared.WorkflowExecutionInfo) error) error {
+	count := 0
+	var nextPageToken []byte
+	for {
+		pageSize := int32(100)
+		ctx, cancel := a.context(taskList)
+		response, err := a.api.ListWorkflow(ctx, &shared.ListWorkflowExecutionsRequest{Query: &query, PageSize: &pageSize, NextPageToken: nextPageToken})
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		for _, info := range response.Executions {
+			if err := fn(info); err != nil {
+				return err
+			}
+			count++
+			if limit > 0 && count >= limit {
+				return nil
+			}
+		}
+
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			return nil
+		}
+	}
+}
+
+func runHistory(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "dump" {
+		_, _ = fmt.Fprintln(a.stderr, "usage: gox-workflow history dump -id <workflow id> [-run-id <run id>] [-file <output file>]")
+		return errUsage
+	}
+
+	fs := newFlagSet(a, "history dump")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	file := fs.String("file", "", "write the history to this file (default stdout) - can be used with replayer.LoadHistoryFile")
+	if err := a.parse(fs, args[1:], "id"); err != nil {
+		return err
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+	history, err := a.api.GetWorkflowHistory(ctx, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(history.Events, "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(*file) == 0 {
+		_, err = fmt.Fprintln(a.stdout, string(data))
+		return err
+	}
+	if err = os.WriteFile(*file, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write history to %s", *file)
+	}
+	_, _ = fmt.Fprintf(a.stderr, "history with %d events written to %s\n", len(history.Events), *file)
+	return nil
+}
+
+const (
+	resetToLastDecision  = "last-decision"
+	resetToFirstDecision = "first-decision"
+)
+
+func runReset(a *app, args []string) error {
+	fs := newFlagSet(a, "reset")
+	var r route
+	r.register(fs)
+	workflowID := fs.String("id", "", "workflow id (required)")
+	runID := fs.String("run-id", "", "run id (default latest run)")
+	eventID := fs.Int64("event-id", 0, "reset to this DecisionTaskCompleted/Failed/TimedOut event id")
+	to := fs.String("to", resetToLastDecision, "reset to "+resetToLastDecision+" or "+resetToFirstDecision+" (used if -event-id is not given)")
+	reason := fs.String("reason", "reset by gox-workflow cli", "reason to reset")
+	if err := a.parse(fs, args, "id"); err != nil {
+		return err
+	}
+	if *to != resetToLastDecision && *to != resetToFirstDecision {
+		return errors.New("-to must be %s or %s", resetToLastDecision, resetToFirstDecision)
+	}
+
+	taskList, err := a.findWorkflow(r, *workflowID, *runID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := a.context(taskList)
+	defer cancel()
+
+	// Run id is required by reset - use the latest run if not given
+	if len(*runID) == 0 {
+		response, err := a.api.DescribeWorkflowExecution(ctx, *workflowID, "")
+		if err != nil {
+			return err
+		}
+		*runID = response.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+	}
+
+	if *eventID == 0 {
+		history, err := a.api.GetWorkflowHistory(ctx, *workflowID, *runID)
+		if err != nil {
+			return err
+		}
+		for _, event := range history.Events {
+			if event.GetEventType() == shared.EventTypeDecisionTaskCompleted {
+				*eventID = event.GetEventId()
+				if *to == resetToFirstDecision {
+					break
+				}
+			}
+		}
+		if *eventID == 0 {
+			return errors.New("no DecisionTaskCompleted event found in the workflow history")
+		}
+	}
+
+	requestID := uuid.NewString()
+	response, err := a.api.ResetWorkflow(ctx, &shared.ResetWorkflowExecutionRequest{
+		WorkflowExecution:     &shared.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+		Reason:                reason,
+		DecisionFinishEventId: eventID,
+		RequestId:             &requestID,
+	})
+	if err != nil {
+		return err
+	}
+
+	t := &table{headers: []string{"WORKFLOW ID", "RESET EVENT ID", "NEW RUN ID"}}
+	t.add(*workflowID, fmt.Sprint(*eventID), response.GetRunId())
+	return a.out.print(map[string]interface{}{"workflow_id": *workflowID, "reset_event_id": *eventID, "run_id": response.GetRunId()}, t)
+}
+
+const (
+	batchSignal    = "signal"
+	batchCancel    = "cancel"
+	batchTerminate = "terminate"
+)
+
+func runBatch(a *app, args []string) error {
+	fs := newFlagSet(a, "batch")
+	var r route
+	r.register(fs)
+	query := fs.String("query", "", "visibility query to select the workflows (required)")
+	action := fs.String("action", "", "signal, cancel or terminate (required)")
+	name := fs.String("name", "", "signal name (for signal action)")
+	input := fs.String("input", "", "signal input as JSON (for signal action)")
+	reason := fs.String("reason", "terminated by gox-workflow cli batch", "reason (for terminate action)")
+	rps := fs.Int("rps", 10, "max operations per second")
+	yes := fs.Bool("yes", false, "run the batch - without this flag only the matching workflows are printed")
+	if err := a.parse(fs, args, "query", "action"); err != nil {
+		return err
+	}
+	switch *action {
+	case batchSignal:
+		if len(*name) == 0 {
+			return errors.New("-name is required for signal action")
+		}
+	case batchCancel, batchTerminate:
+	default:
+		return errors.New("-action must be %s, %s or %s", batchSignal, batchCancel, batchTerminate)
+	}
+	if *rps <= 0 {
+		*rps = 1
+	}
+
+	taskLists, err := a.taskLists(r)
+	if err != nil {
+		return err
+	}
+
+	type batchResult struct {
+		executionRow
+		Error string `json:"error,omitempty"`
+	}
+	results := make([]batchResult, 0)
+	t := &table{headers: append(append([]string{}, executionHeaders...), "ERROR")}
+	throttle := time.NewTicker(time.Second / time.Duration(*rps))
+	defer throttle.Stop()
+
+	for _, tl := range taskLists {
+		group := a.groupOfTaskList(tl)
+		err := a.listWorkflows(tl, *query, 0, func(info *shared.WorkflowExecutionInfo) error {
+			row := batchResult{executionRow: newExecutionRow(group, info)}
+			if *yes {
+				<-throttle.C
+				ctx, cancel := a.context(tl)
+				var err error
+				switch *action {
+				case batchSignal:
+					var arg interface{}
+					if len(*input) > 0 {
+						arg = json.RawMessage(*input)
+					}
+					err = a.api.SignalWorkflow(ctx, row.WorkflowID, row.RunID, *name, arg)
+				case batchCancel:
+					err = a.api.CancelWorkflow(ctx, row.WorkflowID, row.RunID)
+				case batchTerminate:
+					err = a.api.TerminateWorkflow(ctx, row.WorkflowID, row.RunID, *reason, nil)
+				}
+				cancel()
+				row.Error = errorString(err)
+			}
+			results = append(results, row)
+			t.add(append(row.values(), row.Error)...)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list workflows in worker group %s", group)
+		}
+	}
+
+	if !*yes {
+		_, _ = fmt.Fprintf(a.stderr, "%d workflows match the query - run again with -yes to %s them\n", len(results), *action)
+	}
+	return a.out.print(results, t)
+}
+
+func (a *app) printDone(action string, workflowID string, runID string) error {
+	t := &table{headers: []string{"WORKFLOW ID", "RUN ID", "STATUS"}}
+	t.add(workflowID, runID, action)
+	return a.out.print(map[string]string{"workflow_id": workflowID, "run_id": runID, "status": action}, t)
+}
+
+func toJson(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func runVersions(a *app, args []string) error {
+	fs := newFlagSet(a, "versions")
+	var r route
+	r.register(fs)
+	query := fs.String("query", "", "visibility query to select the open workflows e.g. \"WorkflowType = 'x'\" (default all open workflows)")
+	limit := fs.Int("limit", 1000, "max open workflows to check in each worker group (0 for all)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	taskLists, err := a.taskLists(r)
+	if err != nil {
+		return err
+	}
+
+	// Reading the histories takes longer than one call - the timeout is for the whole report
+	ctx, cancel := context.WithTimeout(a.ctx, a.timeout)
+	defer cancel()
+	report, err := versioning.NewReporter(a.api, taskLists, versioning.WithQuery(*query), versioning.WithLimit(*limit)).Report(ctx)
+	if err != nil {
+		return err
+	}
+	if report.Truncated {
+		_, _ = fmt.Fprintf(a.stderr, "limit of %d workflows reached - the report may miss some workflows\n", *limit)
+	}
+
+	t := &table{headers: []string{"CHANGE ID", "VERSION", "OPEN WORKFLOWS", "EXAMPLE"}}
+	for _, c := range report.Changes {
+		for _, v := range c.Versions {
+			example := ""
+			if len(v.Examples) > 0 {
+				example = v.Examples[0].WorkflowID
+			}
+			t.add(c.ChangeID, fmt.Sprint(v.Version), fmt.Sprint(v.OpenWorkflows), example)
+		}
+	}
+	return a.out.print(report, t)
+}
+
+func runBadBinary(a *app, args []string) error {
+	if len(args) == 0 || (args[0] != "mark" && args[0] != "remove" && args[0] != "list") {
+		_, _ = fmt.Fprintln(a.stderr, "usage: gox-workflow bad-binary mark|remove|list -domain <domain> [-checksum <build id>] [-reason <reason>]")
+		return errUsage
+	}
+
+	action := args[0]
+	fs := newFlagSet(a, "bad-binary "+action)
+	domain := fs.String("domain", "", "cadence domain (required)")
+	checksum := fs.String("checksum", "", "binary checksum (build id) - required for mark and remove")
+	reason := fs.String("reason", "marked by gox-workflow cli", "reason to mark the binary as bad")
+	required := []string{"domain"}
+	if action != "list" {
+		required = append(required, "checksum")
+	}
+	if err := a.parse(fs, args[1:], required...); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, a.timeout)
+	defer cancel()
+	var err error
+	switch action {
+	case "mark":
+		err = a.api.MarkBadBinary(ctx, *domain, *checksum, *reason)
+	case "remove":
+		err = a.api.RemoveBadBinary(ctx, *domain, *checksum)
+	}
+	if err != nil {
+		return err
+	}
+
+	badBinaries, err := a.api.ListBadBinaries(ctx, *domain)
+	if err != nil {
+		return err
+	}
+	t := &table{headers: []string{"CHECKSUM", "REASON", "OPERATOR", "CREATED"}}
+	for _, b := range badBinaries {
+		t.add(b.Checksum, b.Reason, b.Operator, b.CreatedTime.Format(time.RFC3339))
+	}
+	return a.out.print(badBinaries, t)
+}
+
+func runConfig(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "lint" {
+		_, _ = fmt.Fprintln(a.stderr, "usage: gox-workflow config lint")
+		return errUsage
+	}
+
+	// Lint only reads the config file - it does not connect to cadence
+	fs := newFlagSet(a, "config lint")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	config, err := LoadConfig(a.configFile)
+	if err != nil {
+		return err
+	}
+
+	report := config.ValidateAll()
+	t := &table{headers: []string{"SEVERITY", "PATH", "MESSAGE"}}
+	for _, i := range report.Issues {
+		t.add(i.Severity, i.Path, i.Message)
+	}
+	for _, d := range report.Defaults {
+		t.add("default", d.Path, "set to "+d.Value)
+	}
+	if err = a.out.print(report, t); err != nil {
+		return err
+	}
+	if errs := report.Errors(); len(errs) > 0 {
+		return errors.New("config %s has %d errors", a.configFile, len(errs))
+	}
+	return nil
+}
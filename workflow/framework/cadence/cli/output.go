@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/cadence/.gen/go/shared"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printer writes the result of a command as JSON or as a table
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// table is the table form of a result
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) add(values ...string) {
+	t.rows = append(t.rows, values)
+}
+
+// print writes v as indented JSON or the table (if table output is selected)
+func (p *printer) print(v interface{}, t *table) error {
+	if p.format == outputTable && t != nil {
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		if len(t.headers) > 0 {
+			_, _ = fmt.Fprintln(tw, strings.Join(t.headers, "\t"))
+		}
+		for _, row := range t.rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, string(data))
+	return err
+}
+
+// executionRow is the JSON/table form of a workflow execution
+type executionRow struct {
+	Group      string `json:"group,omitempty"`
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	StartTime  string `json:"start_time"`
+	CloseTime  string `json:"close_time,omitempty"`
+}
+
+var executionHeaders = []string{"GROUP", "WORKFLOW ID", "RUN ID", "TYPE", "STATUS", "START TIME", "CLOSE TIME"}
+
+func newExecutionRow(group string, info *shared.WorkflowExecutionInfo) executionRow {
+	return executionRow{
+		Group:      group,
+		WorkflowID: info.GetExecution().GetWorkflowId(),
+		RunID:      info.GetExecution().GetRunId(),
+		Type:       info.GetType().GetName(),
+		Status:     executionStatus(info),
+		StartTime:  formatTime(info.StartTime),
+		CloseTime:  formatTime(info.CloseTime),
+	}
+}
+
+func (r executionRow) values() []string {
+	return []string{r.Group, r.WorkflowID, r.RunID, r.Type, r.Status, r.StartTime, r.CloseTime}
+}
+
+// executionStatus returns RUNNING for open workflows or the close status
+func executionStatus(info *shared.WorkflowExecutionInfo) string {
+	if info.CloseStatus == nil {
+		return "RUNNING"
+	}
+	return info.CloseStatus.String()
+}
+
+// formatTime formats the unix nano timestamp used by cadence
+func formatTime(nanos *int64) string {
+	if nanos == nil || *nanos == 0 {
+		return ""
+	}
+	return time.Unix(0, *nanos).UTC().Format(time.RFC3339)
+}
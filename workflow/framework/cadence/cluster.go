@@ -0,0 +1,225 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/client"
+	"go.uber.org/yarpc"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	defaultFailoverCheckInterval = 30 * time.Second
+	clusterDescribeTimeout       = 10 * time.Second
+)
+
+// Cluster is a cadence cluster of a global domain. Name must be the cluster name used by cadence (the
+// active_cluster_name of the domain).
+type Cluster struct {
+	Name     string `json:"name" yaml:"name"`
+	HostPort string `json:"host_port" yaml:"host_port"`
+}
+
+// clusterDetector polls the domain for its active cluster and moves the worker group to it on a failover
+type clusterDetector struct {
+	wrapper  *cadenceWrapperImpl
+	group    string
+	domain   string
+	clusters []*Cluster
+	interval time.Duration
+	slogger  *slog.Logger
+	scope    tally.Scope
+
+	// probes are the domain clients of each cluster - they are used to find the active cluster
+	probes      map[string]client.DomainClient
+	dispatchers []*yarpc.Dispatcher
+
+	cancel context.CancelFunc
+}
+
+// newClusterDetector creates the detector with a domain client for every cluster of the worker group
+func (wrapper *cadenceWrapperImpl) newClusterDetector(wg WorkerGroup) (*clusterDetector, error) {
+	d := &clusterDetector{
+		wrapper:  wrapper,
+		group:    wg.Name,
+		domain:   wg.Domain,
+		clusters: wg.Clusters,
+		interval: wg.FailoverCheckInterval,
+		slogger:  wrapper.logging.slogger.With(slog.String("workerGroup", wg.Name)),
+		scope:    metricScope(wrapper.CrossFunction).Tagged(map[string]string{"worker_group": wg.Name, "domain": wg.Domain}),
+		probes:   map[string]client.DomainClient{},
+	}
+	if d.interval <= 0 {
+		d.interval = defaultFailoverCheckInterval
+	}
+	for _, c := range wg.Clusters {
+		dispatcher, err := startDispatcher(cadenceClientName+"_"+wg.Name+"_probe_"+c.Name, c.HostPort, wg.HostResolveInterval, d.slogger)
+		if err != nil {
+			d.stop()
+			return nil, errors.Wrap(err, "failed to create dispatcher for cluster %s of worker group %s", c.Name, wg.Name)
+		}
+		d.dispatchers = append(d.dispatchers, dispatcher)
+		d.probes[c.Name] = client.NewDomainClient(workflowserviceclient.New(dispatcher.ClientConfig(cadenceService)), &client.Options{})
+	}
+	return d, nil
+}
+
+// activeCluster describes the domain in the clusters (in the configured order) and returns the active cluster
+func (d *clusterDetector) activeCluster(ctx context.Context) (*Cluster, error) {
+	var lastErr error
+	for _, c := range d.clusters {
+		describeCtx, cancel := context.WithTimeout(ctx, clusterDescribeTimeout)
+		resp, err := d.probes[c.Name].Describe(describeCtx, d.domain)
+		cancel()
+		if err != nil {
+			lastErr = err
+			d.slogger.Warn("failed to describe domain in cluster", slog.String("cluster", c.Name), slog.String("error", err.Error()))
+			continue
+		}
+
+		active := resp.GetReplicationConfiguration().GetActiveClusterName()
+		for _, ac := range d.clusters {
+			if ac.Name == active {
+				return ac, nil
+			}
+		}
+		return nil, errors.New("active cluster %s of domain %s is not in the clusters of worker group %s", active, d.domain, d.group)
+	}
+	return nil, errors.Wrap(lastErr, "failed to describe domain %s in any cluster of worker group %s", d.domain, d.group)
+}
+
+// start checks the active cluster every interval until the detector is stopped
+func (d *clusterDetector) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.check(ctx)
+			}
+		}
+	}()
+}
+
+func (d *clusterDetector) check(ctx context.Context) {
+	active, err := d.activeCluster(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.scope.Counter("gox_cluster_check_failed").Inc(1)
+			d.slogger.Error("failed to find active cluster", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	current, err := d.wrapper.failover(ctx, d.group, active)
+	if err != nil {
+		d.scope.Tagged(map[string]string{"from_cluster": current, "to_cluster": active.Name}).Counter("gox_cluster_failover_failed").Inc(1)
+		d.slogger.Error("cadence cluster failover failed - it is retried on the next check",
+			slog.String("from", current), slog.String("to", active.Name), slog.String("error", err.Error()))
+		return
+	}
+	for _, c := range d.clusters {
+		value := 0.0
+		if c.Name == current {
+			value = 1
+		}
+		d.scope.Tagged(map[string]string{"cluster": c.Name}).Gauge("gox_cluster_active").Update(value)
+	}
+}
+
+// stop stops the checks and the dispatchers of the probes. It does not wait for a running check - a failover after
+// stop is not done (see failover).
+func (d *clusterDetector) stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	for _, dispatcher := range d.dispatchers {
+		_ = dispatcher.Stop()
+	}
+}
+
+// failover moves the worker group to the cluster if it is not connected to it. A new worker group is started in the
+// cluster (with the current workers and paused task lists), the routing is switched to it and then the old worker group
+// is stopped. It returns the cluster the worker group is connected to.
+func (wrapper *cadenceWrapperImpl) failover(ctx context.Context, group string, cluster *Cluster) (string, error) {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+
+	// The detector may be stopped while waiting for the lock - the worker group is stopped (or restarted) by a reload
+	// or by Shutdown
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err := wrapper.checkNotClosed(); err != nil {
+		return "", err
+	}
+	var old *cadenceWorker
+	for _, g := range wrapper.groups() {
+		if g.workerGroup.Name == group {
+			old = g
+		}
+	}
+	if old == nil {
+		return "", errors.New("worker group not found (or disabled): %s", group)
+	}
+	if old.cluster == cluster.Name {
+		return old.cluster, nil
+	}
+
+	old.mu.RLock()
+	wg := *old.workerGroup
+	wg.Workers = slices.Clone(wg.Workers)
+	paused := maps.Clone(old.paused)
+	old.mu.RUnlock()
+	wg.HostPort = cluster.HostPort
+
+	next := wrapper.newCadenceWorker(wg, cluster.Name)
+	next.paused = paused
+	if err := next.Start(ctx); err != nil {
+		ch := make(chan error, 2)
+		if err := next.Shutdown(ctx, ch); err == nil {
+			<-ch
+		}
+		if next.dispatcher != nil {
+			_ = next.dispatcher.Stop()
+		}
+		return old.cluster, errors.Wrap(err, "failed to start worker group %s in cluster %s", group, cluster.Name)
+	}
+
+	wrapper.groupsMu.Lock()
+	groups := slices.Clone(wrapper.workerGroups)
+	for i, g := range groups {
+		if g == old {
+			groups[i] = next
+		}
+	}
+	wrapper.workerGroups = groups
+	wrapper.groupsMu.Unlock()
+
+	ch := make(chan error, 2)
+	if err := old.Shutdown(ctx, ch); err == nil {
+		<-ch
+	}
+	if old.dispatcher != nil {
+		_ = old.dispatcher.Stop()
+	}
+
+	metricScope(wrapper.CrossFunction).Tagged(map[string]string{
+		"worker_group": group,
+		"domain":       wg.Domain,
+		"from_cluster": old.cluster,
+		"to_cluster":   cluster.Name,
+	}).Counter("gox_cluster_failover").Inc(1)
+	next.slogger.Warn("cadence worker group moved to the active cluster", slog.String("from", old.cluster), slog.String("to", cluster.Name))
+	return cluster.Name, nil
+}
@@ -0,0 +1,263 @@
+package cadence
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ConfigChange is a difference between the running config and the new config
+type ConfigChange struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// ConfigReloadReport is the result of ReloadConfig
+type ConfigReloadReport struct {
+	// Applied are the changes applied live
+	Applied []ConfigChange `json:"applied"`
+
+	// RestartRequired are the changes which need a restart of the application - the reload is rejected if there is
+	// any of them
+	RestartRequired []ConfigChange `json:"restart_required"`
+}
+
+func (r *ConfigReloadReport) String() string {
+	var sb strings.Builder
+	for _, c := range r.Applied {
+		sb.WriteString(fmt.Sprintf("applied: %s: %s\n", c.Path, c.Change))
+	}
+	for _, c := range r.RestartRequired {
+		sb.WriteString(fmt.Sprintf("restart required: %s: %s\n", c.Path, c.Change))
+	}
+	return sb.String()
+}
+
+// configAction applies one change of a config reload
+type configAction struct {
+	change ConfigChange
+	apply  func(ctx context.Context) error
+}
+
+func (wrapper *cadenceWrapperImpl) ReloadConfig(ctx context.Context, config *Config) (*ConfigReloadReport, error) {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+
+	if err := wrapper.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "new config is not valid")
+	}
+
+	report := &ConfigReloadReport{Applied: make([]ConfigChange, 0), RestartRequired: make([]ConfigChange, 0)}
+	actions := wrapper.diffConfig(config, report)
+	if len(report.RestartRequired) > 0 {
+		paths := make([]string, 0, len(report.RestartRequired))
+		for _, c := range report.RestartRequired {
+			paths = append(paths, c.Path)
+		}
+		return report, errors.New("config reload rejected - changes need a restart: %s", strings.Join(paths, ", "))
+	}
+
+	for _, a := range actions {
+		if err := a.apply(ctx); err != nil {
+			return report, errors.Wrap(err, "failed to apply config change %s", a.change.Path)
+		}
+		report.Applied = append(report.Applied, a.change)
+	}
+	wrapper.config = config
+	return report, nil
+}
+
+// diffConfig returns the actions to move the running worker groups to the new config. Changes which can not be
+// applied live are added to the report.
+func (wrapper *cadenceWrapperImpl) diffConfig(config *Config, report *ConfigReloadReport) []configAction {
+	restart := func(path string, change string) {
+		report.RestartRequired = append(report.RestartRequired, ConfigChange{Path: path, Change: change})
+	}
+	action := func(path string, change string, apply func(ctx context.Context) error) configAction {
+		return configAction{change: ConfigChange{Path: path, Change: change}, apply: apply}
+	}
+
+	old := wrapper.config
+	if old.Disabled != config.Disabled {
+		restart("disabled", fmt.Sprintf("%t -> %t", old.Disabled, config.Disabled))
+	}
+	if old.EnableErrorStackInCadenceLog != config.EnableErrorStackInCadenceLog {
+		restart("enable_error_stack_in_cadence_log", fmt.Sprintf("%t -> %t", old.EnableErrorStackInCadenceLog, config.EnableErrorStackInCadenceLog))
+	}
+	if !reflect.DeepEqual(old.Logging, config.Logging) {
+		restart("logging", "logging config changed")
+	}
+	if !reflect.DeepEqual(old.Client, config.Client) {
+		restart("client", "client retry or circuit breaker config changed")
+	}
+	if old.BuildID != config.BuildID {
+		restart("build_id", fmt.Sprintf("%q -> %q", old.BuildID, config.BuildID))
+	}
+
+	running := map[string]*cadenceWorker{}
+	names := map[string]bool{}
+	for _, g := range wrapper.groups() {
+		running[g.workerGroup.Name] = g
+		names[g.workerGroup.Name] = true
+	}
+	for name := range config.WorkerGroups {
+		names[name] = true
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	// Stops and removals go first so a task list can move from one worker group to another
+	var stops, removes, starts, adds, retunes []configAction
+	for _, name := range sortedNames {
+		path := "worker_groups." + name
+		g, isRunning := running[name]
+		wg, ok := config.WorkerGroups[name]
+		enabled := ok && !wg.Disabled
+
+		switch {
+		case isRunning && !enabled:
+			stops = append(stops, action(path, "worker group stopped", func(ctx context.Context) error {
+				return wrapper.stopWorkerGroup(ctx, g)
+			}))
+
+		case !isRunning && enabled:
+			starts = append(starts, action(path, "worker group started", func(ctx context.Context) error {
+				return wrapper.startWorkerGroup(ctx, wg)
+			}))
+
+		case isRunning && enabled:
+			current := g.workerGroup
+			if current.Domain != wg.Domain {
+				restart(path+".domain", fmt.Sprintf("%s -> %s", current.Domain, wg.Domain))
+			}
+			// The host_port of a worker group with clusters is the active cluster - it is not in the config
+			if len(current.Clusters) > 0 || len(wg.Clusters) > 0 {
+				if !reflect.DeepEqual(current.Clusters, wg.Clusters) || current.FailoverCheckInterval != wg.FailoverCheckInterval {
+					restart(path+".clusters", "clusters changed")
+				}
+			} else if current.HostPort != wg.HostPort {
+				restart(path+".host_port", fmt.Sprintf("%s -> %s", current.HostPort, wg.HostPort))
+			}
+			if current.HostResolveInterval != wg.HostResolveInterval {
+				restart(path+".host_resolve_interval", fmt.Sprintf("%s -> %s", current.HostResolveInterval, wg.HostResolveInterval))
+			}
+			if !reflect.DeepEqual(current.Shadow, wg.Shadow) {
+				restart(path+".shadow", "shadow config changed")
+			}
+			if !reflect.DeepEqual(current.Schedules, wg.Schedules) {
+				restart(path+".schedules", "schedules changed")
+			}
+
+			currentWorkers := map[string]*Worker{}
+			for _, w := range g.workers() {
+				if g.hasTaskList(w.TaskList) {
+					currentWorkers[w.TaskList] = w
+				}
+			}
+			newWorkers := map[string]*Worker{}
+			for _, w := range wg.Workers {
+				if !w.Disabled {
+					newWorkers[w.TaskList] = w
+				}
+			}
+
+			for _, tl := range sortedKeys(currentWorkers) {
+				if _, ok := newWorkers[tl]; !ok {
+					removes = append(removes, action(path+".worker."+tl, "worker stopped", func(ctx context.Context) error {
+						return wrapper.removeWorker(tl)
+					}))
+				}
+			}
+			for _, tl := range sortedKeys(newWorkers) {
+				nw := newWorkers[tl]
+				cw, ok := currentWorkers[tl]
+				switch {
+				case !ok:
+					adds = append(adds, action(path+".worker."+tl, "worker started", func(ctx context.Context) error {
+						return wrapper.addWorker(name, *nw)
+					}))
+				case !reflect.DeepEqual(cw.Shadow, nw.Shadow):
+					restart(path+".worker."+tl+".shadow", "shadow config changed")
+				case !cw.sameTuning(nw):
+					retunes = append(retunes, action(path+".worker."+tl, "worker restarted with new tuning", func(ctx context.Context) error {
+						return g.retuneTaskListWorker(nw)
+					}))
+				}
+			}
+		}
+	}
+	return slices.Concat(stops, removes, starts, adds, retunes)
+}
+
+// stopWorkerGroup removes the worker group from the routing and stops it
+func (wrapper *cadenceWrapperImpl) stopWorkerGroup(ctx context.Context, g *cadenceWorker) error {
+	wrapper.stopDetector(g.workerGroup.Name)
+	wrapper.groupsMu.Lock()
+	wrapper.workerGroups = slices.DeleteFunc(slices.Clone(wrapper.workerGroups), func(c *cadenceWorker) bool { return c == g })
+	wrapper.groupsMu.Unlock()
+
+	ch := make(chan error, 2)
+	if err := g.Shutdown(ctx, ch); err == nil {
+		<-ch
+	}
+	if g.dispatcher != nil {
+		if err := g.dispatcher.Stop(); err != nil {
+			return errors.Wrap(err, "failed to stop dispatcher of worker group %s", g.workerGroup.Name)
+		}
+	}
+	return nil
+}
+
+// watchConfig reloads the config when the config source changes
+func (wrapper *cadenceWrapperImpl) watchConfig(ctx context.Context) {
+	logger := wrapper.logging.slogger
+	err := wrapper.configSource.Watch(ctx, func() {
+		config, err := wrapper.configSource.Load(ctx)
+		if err != nil {
+			logger.Error("failed to load cadence config", slog.String("error", err.Error()))
+			return
+		}
+		report, err := wrapper.ReloadConfig(ctx, config)
+		if err != nil {
+			logger.Error("cadence config reload failed", slog.String("error", err.Error()), slog.Any("report", report))
+			return
+		}
+		if len(report.Applied) > 0 {
+			logger.Info("cadence config reloaded", slog.Any("applied", report.Applied))
+		}
+	})
+	if err != nil {
+		logger.Error("cadence config watch stopped", slog.String("error", err.Error()))
+	}
+}
+
+// sameTuning returns true if the workers have the same tuning
+func (s *Worker) sameTuning(o *Worker) bool {
+	a, b := *s, *o
+	a.Disabled, b.Disabled = false, false
+	a.Shadow, b.Shadow = nil, nil
+	return a == b
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package cadence
+
+import (
+	"bytes"
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-base/v2/serialization"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConfigSource provides the config of the cadence client. With WithConfigSource the client watches the source and
+// applies the changes with ReloadConfig.
+type ConfigSource interface {
+	// Load returns the current config
+	Load(ctx context.Context) (*Config, error)
+
+	// Watch calls onChange every time the config may have changed. It blocks until ctx is done.
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// WithConfigSource reloads the config from the source when it changes. The reload result is logged; changes which
+// need a restart are rejected (and logged) without applying any change.
+func WithConfigSource(source ConfigSource) Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		wrapper.configSource = source
+	}
+}
+
+// NewFileConfigSource reads the config YAML file (with env variables expanded). The file is polled every interval
+// (default 5s) and a change in its content is reported - this also works for files replaced by a rename or a
+// symlink swap (e.g. kubernetes config maps).
+func NewFileConfigSource(file string, interval time.Duration) ConfigSource {
+	return &pollingConfigSource{
+		name:     "file " + file,
+		interval: interval,
+		read: func() ([]byte, error) {
+			return os.ReadFile(file)
+		},
+	}
+}
+
+// NewEnvConfigSource reads the config YAML from the env variable (with env variables expanded). The env variable is
+// polled every interval (default 5s).
+func NewEnvConfigSource(name string, interval time.Duration) ConfigSource {
+	return &pollingConfigSource{
+		name:     "env " + name,
+		interval: interval,
+		read: func() ([]byte, error) {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return nil, errors.New("env variable is not set: %s", name)
+			}
+			return []byte(value), nil
+		},
+	}
+}
+
+// pollingConfigSource reads the YAML config with read and reports a change when the content changes
+type pollingConfigSource struct {
+	name     string
+	interval time.Duration
+	read     func() ([]byte, error)
+}
+
+func (s *pollingConfigSource) Load(ctx context.Context) (*Config, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config from %s", s.name)
+	}
+	c := &Config{}
+	if err := serialization.ReadYamlFromStringWithEnvVar(string(data), c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config from %s", s.name)
+	}
+	return c, nil
+}
+
+func (s *pollingConfigSource) Watch(ctx context.Context, onChange func()) error {
+	interval := s.interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, _ := s.read()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// A read error (e.g. file being replaced) is not a change - the next tick reads it again
+			data, err := s.read()
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			onChange()
+		}
+	}
+}
+
+// ConfigProvider is a config source updated by the application e.g. from a remote config service
+type ConfigProvider struct {
+	mu       sync.Mutex
+	config   *Config
+	watchers map[chan struct{}]bool
+}
+
+// NewConfigProvider creates a config provider with the initial config
+func NewConfigProvider(config *Config) *ConfigProvider {
+	return &ConfigProvider{config: config, watchers: map[chan struct{}]bool{}}
+}
+
+// Update sets the config and notifies the watchers
+func (p *ConfigProvider) Update(config *Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+	for ch := range p.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *ConfigProvider) Load(ctx context.Context) (*Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.config == nil {
+		return nil, errors.New("config provider has no config")
+	}
+	return p.config, nil
+}
+
+func (p *ConfigProvider) Watch(ctx context.Context, onChange func()) error {
+	ch := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.watchers[ch] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.watchers, ch)
+		p.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+			onChange()
+		}
+	}
+}
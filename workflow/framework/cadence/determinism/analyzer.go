@@ -0,0 +1,398 @@
+// Package determinism provides a go/analysis analyzer which reports non-deterministic code in cadence workflow
+// functions.
+//
+// A function is treated as a workflow function if its first parameter is workflow.Context, or it is passed to one of
+// the registration calls (workflow.Register, workflow.RegisterWithOptions, worker RegisterWorkflow* or
+// cadence.NewWorkflowDef*).
+//
+// Reported constructs (with the replacement to use):
+//   - time.Now, time.Since, time.Until           -> workflow.Now
+//   - time.Sleep                                 -> workflow.Sleep
+//   - time.After, time.Tick, time.NewTimer, ...   -> workflow.NewTimer
+//   - go statement                               -> workflow.Go
+//   - range over a map                           -> iterate over sorted keys
+//   - math/rand, crypto/rand, uuid, os.Getenv     -> workflow.SideEffect
+//   - native channel send/receive/range, select  -> workflow.Channel, workflow.NewSelector
+//
+// Code inside functions passed to workflow.SideEffect and workflow.MutableSideEffect is not checked.
+//
+// Allow list:
+//   - add "//gox:nondeterministic-ok" comment on the line (or on its own line above) to ignore a finding
+//   - use -allow flag with comma separated list of functions to ignore e.g. -allow=time.Now,os.Getenv
+package determinism
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	workflowPkgPath = "go.uber.org/cadence/workflow"
+	internalPkgPath = "go.uber.org/cadence/internal" // workflow.Context is an alias of internal.Context
+	workerPkgPath   = "go.uber.org/cadence/worker"
+	cadencePkgPath  = "github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+
+	// IgnoreDirective is the comment used to ignore a finding on a line
+	IgnoreDirective = "//gox:nondeterministic-ok"
+)
+
+// Analyzer reports non-deterministic code in cadence workflow functions
+var Analyzer = &analysis.Analyzer{
+	Name: "goxdeterminism",
+	Doc:  "reports non-deterministic code (time.Now, go, map iteration, rand, native channels...) in cadence workflow functions",
+	Run:  run,
+}
+
+var allow string
+
+func init() {
+	Analyzer.Flags.StringVar(&allow, "allow", "", "comma separated list of functions which are allowed in workflow code e.g. time.Now,os.Getenv")
+}
+
+// forbiddenCall is a function which must not be called from workflow code
+type forbiddenCall struct {
+	replacement string
+	fixName     string // name of the workflow.* function for auto fix (if the arguments are compatible)
+}
+
+// forbiddenCalls are keyed by <package path>.<function name>
+var forbiddenCalls = map[string]forbiddenCall{
+	"time.Now":                         {replacement: "workflow.Now(ctx)", fixName: "Now"},
+	"time.Since":                       {replacement: "workflow.Now(ctx).Sub(t)"},
+	"time.Until":                       {replacement: "t.Sub(workflow.Now(ctx))"},
+	"time.Sleep":                       {replacement: "workflow.Sleep(ctx, d)", fixName: "Sleep"},
+	"time.After":                       {replacement: "workflow.NewTimer(ctx, d)"},
+	"time.AfterFunc":                   {replacement: "workflow.NewTimer(ctx, d) with workflow.Go"},
+	"time.Tick":                        {replacement: "workflow.NewTimer(ctx, d) in a loop"},
+	"time.NewTimer":                    {replacement: "workflow.NewTimer(ctx, d)"},
+	"time.NewTicker":                   {replacement: "workflow.NewTimer(ctx, d) in a loop"},
+	"os.Getenv":                        {replacement: "workflow.SideEffect"},
+	"os.LookupEnv":                     {replacement: "workflow.SideEffect"},
+	"github.com/google/uuid.New":       {replacement: "workflow.SideEffect"},
+	"github.com/google/uuid.NewString": {replacement: "workflow.SideEffect"},
+	"github.com/google/uuid.NewRandom": {replacement: "workflow.SideEffect"},
+}
+
+// forbiddenPackages - all functions and methods of these packages must not be called from workflow code
+var forbiddenPackages = map[string]string{
+	"math/rand":    "workflow.SideEffect",
+	"math/rand/v2": "workflow.SideEffect",
+	"crypto/rand":  "workflow.SideEffect",
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{
+		pass:        pass,
+		allowed:     map[string]bool{},
+		registered:  map[*types.Func]bool{},
+		ignored:     map[string]bool{},
+		ignoredNext: map[string]bool{},
+	}
+	for _, a := range strings.Split(allow, ",") {
+		if a = strings.TrimSpace(a); len(a) > 0 {
+			c.allowed[a] = true
+		}
+	}
+	c.collectIgnoreDirectives()
+	c.collectRegisteredWorkflows()
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			fn, _ := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+			if ctxName, ok := c.workflowContextParam(fd.Type); ok || c.registered[fn] {
+				c.checkWorkflowBody(file, fd.Body, ctxName)
+				continue
+			}
+
+			// A function literal with workflow.Context param inside a normal function is also a workflow function
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.FuncLit); ok {
+					if ctxName, ok := c.workflowContextParam(lit.Type); ok {
+						c.checkWorkflowBody(file, lit.Body, ctxName)
+						return false
+					}
+				}
+				return true
+			})
+		}
+	}
+	return nil, nil
+}
+
+type checker struct {
+	pass       *analysis.Pass
+	allowed    map[string]bool
+	registered map[*types.Func]bool
+	ignored    map[string]bool // file:line with the ignore directive
+
+	// ignoredNext are the file:line with only the ignore directive - it also ignores the findings of the next line
+	ignoredNext map[string]bool
+}
+
+// collectIgnoreDirectives finds all lines with IgnoreDirective
+func (c *checker) collectIgnoreDirectives() {
+	for _, file := range c.pass.Files {
+		for _, cg := range file.Comments {
+			for _, comment := range cg.List {
+				if strings.HasPrefix(comment.Text, IgnoreDirective) {
+					p := c.pass.Fset.Position(comment.Pos())
+					c.ignored[lineKey(p.Filename, p.Line)] = true
+					if c.onlyCommentOnLine(p) {
+						c.ignoredNext[lineKey(p.Filename, p.Line)] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// onlyCommentOnLine returns true if there is no code before the comment on its line
+func (c *checker) onlyCommentOnLine(p token.Position) bool {
+	if c.pass.ReadFile == nil {
+		return false
+	}
+	content, err := c.pass.ReadFile(p.Filename)
+	if err != nil || p.Offset > len(content) || p.Column < 1 || p.Offset < p.Column-1 {
+		return false
+	}
+	return len(strings.TrimSpace(string(content[p.Offset-(p.Column-1):p.Offset]))) == 0
+}
+
+// collectRegisteredWorkflows finds functions passed to the workflow registration calls
+func (c *checker) collectRegisteredWorkflows() {
+	for _, file := range c.pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+			if !ok || fn.Pkg() == nil {
+				return true
+			}
+
+			var arg ast.Expr
+			switch {
+			case fn.Pkg().Path() == workflowPkgPath && (fn.Name() == "Register" || fn.Name() == "RegisterWithOptions"):
+				arg = call.Args[0]
+			case fn.Pkg().Path() == workerPkgPath && strings.HasPrefix(fn.Name(), "RegisterWorkflow"):
+				arg = call.Args[0]
+			case fn.Pkg().Path() == cadencePkgPath && fn.Name() == "NewWorkflowDef":
+				arg = call.Args[0]
+			case fn.Pkg().Path() == cadencePkgPath && fn.Name() == "NewWorkflowDefWithName" && len(call.Args) > 1:
+				arg = call.Args[1]
+			default:
+				return true
+			}
+
+			switch a := unparen(arg).(type) {
+			case *ast.Ident:
+				if f, ok := c.pass.TypesInfo.Uses[a].(*types.Func); ok {
+					c.registered[f] = true
+				}
+			case *ast.SelectorExpr:
+				if f, ok := c.pass.TypesInfo.Uses[a.Sel].(*types.Func); ok {
+					c.registered[f] = true
+				}
+			}
+			return true
+		})
+	}
+}
+
+// workflowContextParam returns the name of the first param if it is workflow.Context
+func (c *checker) workflowContextParam(ft *ast.FuncType) (string, bool) {
+	if ft.Params == nil || len(ft.Params.List) == 0 {
+		return "", false
+	}
+	first := ft.Params.List[0]
+	named, ok := types.Unalias(c.pass.TypesInfo.TypeOf(first.Type)).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Name() != "Context" {
+		return "", false
+	}
+	if path := named.Obj().Pkg().Path(); path != workflowPkgPath && path != internalPkgPath {
+		return "", false
+	}
+	if len(first.Names) == 0 || first.Names[0].Name == "_" {
+		return "", true
+	}
+	return first.Names[0].Name, true
+}
+
+// checkWorkflowBody reports all non-deterministic constructs in the body of a workflow function
+func (c *checker) checkWorkflowBody(file *ast.File, body *ast.BlockStmt, ctxName string) {
+	skip := map[*ast.FuncLit]bool{}
+
+	// The send and receive of a select case are reported with the select statement
+	comms := map[ast.Node]bool{}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			if skip[node] {
+				return false
+			}
+			// A nested function literal with its own workflow.Context param uses its own ctx name
+			if name, ok := c.workflowContextParam(node.Type); ok && name != ctxName {
+				c.checkWorkflowBody(file, node.Body, name)
+				return false
+			}
+
+		case *ast.GoStmt:
+			c.report(node.Pos(), nil, "go statement in workflow function, use workflow.Go(ctx, func(ctx workflow.Context) {...})")
+
+		case *ast.RangeStmt:
+			switch c.pass.TypesInfo.TypeOf(node.X).Underlying().(type) {
+			case *types.Map:
+				c.report(node.Pos(), nil, "range over map in workflow function is not deterministic, iterate over sorted keys")
+			case *types.Chan:
+				c.report(node.Pos(), nil, "range over native channel in workflow function, use workflow.Channel")
+			}
+
+		case *ast.SelectStmt:
+			c.report(node.Pos(), nil, "select statement in workflow function, use workflow.NewSelector(ctx)")
+			for _, stmt := range node.Body.List {
+				if cc, ok := stmt.(*ast.CommClause); ok && cc.Comm != nil {
+					comms[commOp(cc.Comm)] = true
+				}
+			}
+
+		case *ast.SendStmt:
+			if !comms[node] {
+				c.report(node.Pos(), nil, "send on native channel in workflow function, use workflow.Channel")
+			}
+
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW && !comms[node] {
+				c.report(node.Pos(), nil, "receive from native channel in workflow function, use workflow.Channel")
+			}
+
+		case *ast.CallExpr:
+			fn, ok := typeutil.Callee(c.pass.TypesInfo, node).(*types.Func)
+			if !ok || fn.Pkg() == nil {
+				return true
+			}
+
+			// Code inside side effects is allowed to be non-deterministic
+			if fn.Pkg().Path() == workflowPkgPath && (fn.Name() == "SideEffect" || fn.Name() == "MutableSideEffect") {
+				for _, arg := range node.Args {
+					if lit, ok := unparen(arg).(*ast.FuncLit); ok {
+						skip[lit] = true
+					}
+				}
+				return true
+			}
+
+			c.checkCall(file, node, fn, ctxName)
+		}
+		return true
+	})
+}
+
+func (c *checker) checkCall(file *ast.File, call *ast.CallExpr, fn *types.Func, ctxName string) {
+	pkgPath := fn.Pkg().Path()
+	qualifiedName := pkgPath + "." + fn.Name()
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		qualifiedName = ""
+	}
+	if c.allowed[qualifiedName] || c.allowed[pkgPath] {
+		return
+	}
+
+	if replacement, ok := forbiddenPackages[pkgPath]; ok {
+		c.report(call.Pos(), nil, "call to %s.%s in workflow function is not deterministic, use %s", pkgPath, fn.Name(), replacement)
+		return
+	}
+
+	if fc, ok := forbiddenCalls[qualifiedName]; ok {
+		var fix *analysis.SuggestedFix
+		if len(fc.fixName) > 0 && len(ctxName) > 0 {
+			fix = c.suggestedFix(file, call, fc.fixName, ctxName)
+		}
+		c.report(call.Pos(), fix, "call to %s in workflow function is not deterministic, use %s", qualifiedName, fc.replacement)
+	}
+}
+
+// suggestedFix replaces time.Now() with workflow.Now(ctx) and time.Sleep(d) with workflow.Sleep(ctx, d)
+func (c *checker) suggestedFix(file *ast.File, call *ast.CallExpr, fixName string, ctxName string) *analysis.SuggestedFix {
+	workflowName := ""
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == workflowPkgPath {
+			workflowName = "workflow"
+			if imp.Name != nil {
+				workflowName = imp.Name.Name
+			}
+		}
+	}
+	if len(workflowName) == 0 || workflowName == "_" || workflowName == "." {
+		return nil
+	}
+
+	newText := workflowName + "." + fixName + "(" + ctxName
+	if len(call.Args) > 0 {
+		newText += ", "
+	}
+	return &analysis.SuggestedFix{
+		Message: "use " + workflowName + "." + fixName,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     call.Fun.Pos(),
+			End:     call.Lparen + 1,
+			NewText: []byte(newText),
+		}},
+	}
+}
+
+func (c *checker) report(pos token.Pos, fix *analysis.SuggestedFix, format string, args ...interface{}) {
+	p := c.pass.Fset.Position(pos)
+	if c.ignored[lineKey(p.Filename, p.Line)] || c.ignoredNext[lineKey(p.Filename, p.Line-1)] {
+		return
+	}
+	d := analysis.Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)}
+	if fix != nil {
+		d.SuggestedFixes = []analysis.SuggestedFix{*fix}
+	}
+	c.pass.Report(d)
+}
+
+// commOp returns the send statement or the receive expression of a select case
+func commOp(comm ast.Stmt) ast.Node {
+	var x ast.Expr
+	switch stmt := comm.(type) {
+	case *ast.SendStmt:
+		return stmt
+	case *ast.ExprStmt:
+		x = stmt.X
+	case *ast.AssignStmt:
+		if len(stmt.Rhs) == 1 {
+			x = stmt.Rhs[0]
+		}
+	}
+	if x == nil {
+		return comm
+	}
+	return unparen(x)
+}
+
+func lineKey(filename string, line int) string {
+	return filename + ":" + strconv.Itoa(line)
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
@@ -0,0 +1,20 @@
+package determinism
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "findings")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "registered", "ignore")
+}
+
+func TestAnalyzerAllow(t *testing.T) {
+	if err := Analyzer.Flags.Set("allow", "time.Now,math/rand"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = Analyzer.Flags.Set("allow", "") }()
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "allow")
+}
@@ -0,0 +1,16 @@
+package allow
+
+import (
+	"math/rand"
+	"os"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+)
+
+func Workflow(ctx workflow.Context) error {
+	_ = time.Now()
+	_ = rand.Intn(10)
+	_ = os.Getenv("HOME") // want `call to os.Getenv in workflow function is not deterministic`
+	return nil
+}
@@ -0,0 +1,64 @@
+package findings
+
+import (
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/cadence/workflow"
+)
+
+func Workflow(ctx workflow.Context, m map[string]int, ch chan int) error {
+	start := time.Now()       // want `call to time.Now in workflow function is not deterministic, use workflow.Now\(ctx\)`
+	time.Sleep(time.Second)   // want `call to time.Sleep in workflow function is not deterministic, use workflow.Sleep\(ctx, d\)`
+	_ = time.Since(start)     // want `call to time.Since in workflow function is not deterministic`
+	<-time.After(time.Second) // want `call to time.After in workflow function is not deterministic` `receive from native channel`
+
+	go func() {}() // want `go statement in workflow function, use workflow.Go`
+
+	for k := range m { // want `range over map in workflow function is not deterministic`
+		_ = k
+	}
+	for v := range ch { // want `range over native channel in workflow function`
+		_ = v
+	}
+
+	ch <- 1  // want `send on native channel in workflow function`
+	_ = <-ch // want `receive from native channel in workflow function`
+
+	select { // want `select statement in workflow function, use workflow.NewSelector\(ctx\)`
+	case v := <-ch:
+		_ = v
+	case ch <- 2:
+	case <-ch:
+	}
+
+	_ = rand.Intn(10)     // want `call to math/rand.Intn in workflow function is not deterministic, use workflow.SideEffect`
+	_ = os.Getenv("HOME") // want `call to os.Getenv in workflow function is not deterministic`
+	_ = uuid.NewString()  // want `call to github.com/google/uuid.NewString in workflow function is not deterministic`
+
+	// Code inside side effects is not checked
+	workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return uuid.NewString()
+	})
+	workflow.MutableSideEffect(ctx, "id", func(ctx workflow.Context) interface{} {
+		return time.Now()
+	}, nil)
+	return nil
+}
+
+// Activity is not a workflow function - nothing is reported
+func Activity(m map[string]int) time.Time {
+	for range m {
+	}
+	return time.Now()
+}
+
+// Setup is a normal function with a workflow function literal
+func Setup() {
+	workflow.Register(func(wctx workflow.Context) error {
+		_ = time.Now() // want `call to time.Now in workflow function`
+		return nil
+	})
+}
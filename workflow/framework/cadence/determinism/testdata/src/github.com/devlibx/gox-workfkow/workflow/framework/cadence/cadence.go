@@ -0,0 +1,14 @@
+// Package cadence is a stub of the gox cadence package for the analyzer tests
+package cadence
+
+import "go.uber.org/cadence/workflow"
+
+type WorkflowDef[In, Out any] struct{}
+
+func NewWorkflowDef[In, Out any](fn func(ctx workflow.Context, in In) (Out, error)) WorkflowDef[In, Out] {
+	return WorkflowDef[In, Out]{}
+}
+
+func NewWorkflowDefWithName[In, Out any](name string, fn func(ctx workflow.Context, in In) (Out, error)) WorkflowDef[In, Out] {
+	return WorkflowDef[In, Out]{}
+}
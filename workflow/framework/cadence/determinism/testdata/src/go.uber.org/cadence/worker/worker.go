@@ -0,0 +1,6 @@
+// Package worker is a stub of the cadence worker package for the analyzer tests
+package worker
+
+type Worker interface {
+	RegisterWorkflow(w interface{})
+}
@@ -0,0 +1,28 @@
+// Package workflow is a stub of the cadence workflow package for the analyzer tests
+package workflow
+
+import "time"
+
+type Context interface {
+	Value(key interface{}) interface{}
+}
+
+type Value interface{}
+
+type RegisterOptions struct{ Name string }
+
+func Register(workflowFunc interface{}) {}
+
+func RegisterWithOptions(workflowFunc interface{}, options RegisterOptions) {}
+
+func Now(ctx Context) time.Time { return time.Time{} }
+
+func Sleep(ctx Context, d time.Duration) error { return nil }
+
+func Go(ctx Context, f func(ctx Context)) {}
+
+func SideEffect(ctx Context, f func(ctx Context) interface{}) Value { return nil }
+
+func MutableSideEffect(ctx Context, id string, f func(ctx Context) interface{}, equals func(a, b interface{}) bool) Value {
+	return nil
+}
@@ -0,0 +1,20 @@
+package ignore
+
+import (
+	"time"
+
+	"go.uber.org/cadence/workflow"
+)
+
+func Workflow(ctx workflow.Context, m map[string]int) error {
+	_ = time.Now() //gox:nondeterministic-ok
+
+	//gox:nondeterministic-ok
+	_ = time.Now()
+
+	// A directive after code on the line does not ignore the next line
+	_ = time.Now() //gox:nondeterministic-ok
+	for range m {  // want `range over map in workflow function is not deterministic`
+	}
+	return nil
+}
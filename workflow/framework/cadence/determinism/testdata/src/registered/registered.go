@@ -0,0 +1,45 @@
+package registered
+
+import (
+	"time"
+
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+// Without a workflow.Context param the functions are found with their registration
+
+func Registered() error {
+	time.Sleep(time.Second) // want `call to time.Sleep in workflow function`
+	return nil
+}
+
+func RegisteredWithOptions() error {
+	_ = time.Now() // want `call to time.Now in workflow function`
+	return nil
+}
+
+func RegisteredOnWorker() error {
+	go func() {}() // want `go statement in workflow function`
+	return nil
+}
+
+func NotRegistered() error {
+	_ = time.Now()
+	return nil
+}
+
+type Input struct{}
+
+func Typed(ctx workflow.Context, in Input) (string, error) {
+	return time.Now().String(), nil // want `call to time.Now in workflow function`
+}
+
+var typed = cadence.NewWorkflowDefWithName("typed", Typed)
+
+func Register(w worker.Worker) {
+	workflow.Register(Registered)
+	workflow.RegisterWithOptions(RegisteredWithOptions, workflow.RegisterOptions{Name: "x"})
+	w.RegisterWorkflow(RegisteredOnWorker)
+}
@@ -0,0 +1,280 @@
+// Package dsl runs simple orchestrations written in YAML (or JSON) without Go workflow code.
+//
+// A definition has a list of steps. A step is one of activity, sequence, parallel, choice, wait_signal or timer,
+// and any step can have a retry policy:
+//
+//	name: onboarding
+//	version: 2
+//	task_list: server_1_ts_1
+//	activity_options:
+//	  start_to_close_timeout: 1m
+//	steps:
+//	  - activity: { name: main.CreateUser, args: ["$user"], result: account }
+//	    retry: { max_attempts: 3, initial_interval: 1s }
+//	  - parallel:
+//	      - activity: { name: main.SendWelcomeMail, args: ["$account.email"] }
+//	      - activity: { name: main.CreateWallet, args: ["$account.id"], result: wallet }
+//	  - choice:
+//	      - when: { var: account.tier, equals: gold }
+//	        steps:
+//	          - wait_signal: { name: kyc_done, result: kyc, timeout: 24h }
+//	      - steps:
+//	          - timer: { duration: 1h }
+//
+// Arguments starting with "$" refer to variables (dot separated paths into maps). Variables come from the definition,
+// the start call and the results of activities and signals.
+//
+// All definitions run in one interpreter workflow (WorkflowName) which is registered with Registry.Register.
+package dsl
+
+import (
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"time"
+)
+
+// Step kinds
+const (
+	KindActivity   = "activity"
+	KindSequence   = "sequence"
+	KindParallel   = "parallel"
+	KindChoice     = "choice"
+	KindWaitSignal = "wait_signal"
+	KindTimer      = "timer"
+)
+
+// Definition is a versioned workflow definition
+type Definition struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Version is required and starts at 1 - version 0 means the latest version in Get and Start
+	Version int `yaml:"version" json:"version"`
+
+	// TaskList is where the interpreter workflow runs (and activities run by default)
+	TaskList string `yaml:"task_list" json:"task_list"`
+
+	// ExecutionTimeout is the workflow execution timeout (default 24h)
+	ExecutionTimeout time.Duration `yaml:"execution_timeout" json:"execution_timeout"`
+
+	// ActivityOptions are the defaults for all activity steps
+	ActivityOptions ActivityOptions `yaml:"activity_options" json:"activity_options"`
+
+	// Variables are the initial variables - the variables given in the start call override them
+	Variables map[string]interface{} `yaml:"variables" json:"variables"`
+
+	Steps []*Step `yaml:"steps" json:"steps"`
+}
+
+// ActivityOptions are the timeouts of activity steps
+type ActivityOptions struct {
+	TaskList               string        `yaml:"task_list" json:"task_list"`
+	ScheduleToStartTimeout time.Duration `yaml:"schedule_to_start_timeout" json:"schedule_to_start_timeout"`
+	StartToCloseTimeout    time.Duration `yaml:"start_to_close_timeout" json:"start_to_close_timeout"`
+	HeartbeatTimeout       time.Duration `yaml:"heartbeat_timeout" json:"heartbeat_timeout"`
+}
+
+// Step is a single step - exactly one of the step kinds must be set
+type Step struct {
+	// ID is shown in the current step query. It is set to the path of the step (e.g. "steps[1].parallel[0]") if empty.
+	ID string `yaml:"id" json:"id"`
+
+	Activity   *ActivityStep   `yaml:"activity" json:"activity"`
+	Sequence   []*Step         `yaml:"sequence" json:"sequence"`
+	Parallel   []*Step         `yaml:"parallel" json:"parallel"`
+	Choice     []*ChoiceBranch `yaml:"choice" json:"choice"`
+	WaitSignal *SignalStep     `yaml:"wait_signal" json:"wait_signal"`
+	Timer      *TimerStep      `yaml:"timer" json:"timer"`
+
+	// Retry runs the step again if it fails
+	Retry *RetryPolicy `yaml:"retry" json:"retry"`
+
+	kind string
+}
+
+// ActivityStep runs an activity
+type ActivityStep struct {
+	Name string        `yaml:"name" json:"name"`
+	Args []interface{} `yaml:"args" json:"args"`
+
+	// Result is the variable which gets the result of the activity
+	Result string `yaml:"result" json:"result"`
+
+	// Options override the definition activity options
+	Options *ActivityOptions `yaml:"options" json:"options"`
+}
+
+// ChoiceBranch runs its steps if the condition is true. The first branch with a true condition runs. A branch
+// without a condition is the default branch.
+type ChoiceBranch struct {
+	When  *Condition `yaml:"when" json:"when"`
+	Steps []*Step    `yaml:"steps" json:"steps"`
+}
+
+// Condition checks a variable - exactly one of Equals, NotEquals and Exists must be set. Values are compared by their
+// string form, so 5 (YAML) equals 5.0 (JSON result).
+type Condition struct {
+	Var       string      `yaml:"var" json:"var"`
+	Equals    interface{} `yaml:"equals" json:"equals"`
+	NotEquals interface{} `yaml:"not_equals" json:"not_equals"`
+	Exists    *bool       `yaml:"exists" json:"exists"`
+}
+
+// SignalStep waits for a signal
+type SignalStep struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Result is the variable which gets the signal value
+	Result string `yaml:"result" json:"result"`
+
+	// Timeout fails the step if the signal is not received in time (0 waits forever)
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+// TimerStep waits for the duration
+type TimerStep struct {
+	Duration time.Duration `yaml:"duration" json:"duration"`
+}
+
+// RetryPolicy runs a failed step again with exponential backoff
+type RetryPolicy struct {
+	MaxAttempts        int           `yaml:"max_attempts" json:"max_attempts"`
+	InitialInterval    time.Duration `yaml:"initial_interval" json:"initial_interval"`
+	BackoffCoefficient float64       `yaml:"backoff_coefficient" json:"backoff_coefficient"`
+	MaxInterval        time.Duration `yaml:"max_interval" json:"max_interval"`
+}
+
+// Validate checks the definition and sets the defaults. Activity names are checked with isActivity if it is not nil.
+func (d *Definition) Validate(isActivity func(name string) bool) error {
+	switch {
+	case len(d.Name) == 0:
+		return errors.New("definition name is required")
+	case d.Version < 1:
+		return errors.New("version must be at least 1 in definition %s - found %d", d.Name, d.Version)
+	case len(d.TaskList) == 0:
+		return errors.New("task_list is required in definition %s", d.Name)
+	case len(d.Steps) == 0:
+		return errors.New("steps are required in definition %s", d.Name)
+	}
+
+	if d.ExecutionTimeout == 0 {
+		d.ExecutionTimeout = 24 * time.Hour
+	}
+	if len(d.ActivityOptions.TaskList) == 0 {
+		d.ActivityOptions.TaskList = d.TaskList
+	}
+	if d.ActivityOptions.ScheduleToStartTimeout == 0 {
+		d.ActivityOptions.ScheduleToStartTimeout = 10 * time.Minute
+	}
+	if d.ActivityOptions.StartToCloseTimeout == 0 {
+		d.ActivityOptions.StartToCloseTimeout = time.Minute
+	}
+
+	if err := validateSteps(d.Steps, "steps", isActivity); err != nil {
+		return errors.Wrap(err, "bad definition %s version %d", d.Name, d.Version)
+	}
+	return nil
+}
+
+func validateSteps(steps []*Step, path string, isActivity func(name string) bool) error {
+	if len(steps) == 0 {
+		return errors.New("%s: no steps", path)
+	}
+	for i, step := range steps {
+		if step == nil {
+			return errors.New("%s[%d]: empty step", path, i)
+		}
+		if err := step.validate(fmt.Sprintf("%s[%d]", path, i), isActivity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Step) validate(path string, isActivity func(name string) bool) error {
+	if len(s.ID) == 0 {
+		s.ID = path
+	}
+
+	kinds := 0
+	if s.Activity != nil {
+		kinds, s.kind = kinds+1, KindActivity
+	}
+	if s.Sequence != nil {
+		kinds, s.kind = kinds+1, KindSequence
+	}
+	if s.Parallel != nil {
+		kinds, s.kind = kinds+1, KindParallel
+	}
+	if s.Choice != nil {
+		kinds, s.kind = kinds+1, KindChoice
+	}
+	if s.WaitSignal != nil {
+		kinds, s.kind = kinds+1, KindWaitSignal
+	}
+	if s.Timer != nil {
+		kinds, s.kind = kinds+1, KindTimer
+	}
+	if kinds != 1 {
+		return errors.New("%s: a step must have exactly one of activity, sequence, parallel, choice, wait_signal or timer", path)
+	}
+
+	if s.Retry != nil && s.Retry.MaxAttempts < 1 {
+		return errors.New("%s: retry.max_attempts must be at least 1", path)
+	}
+
+	switch s.kind {
+	case KindActivity:
+		if len(s.Activity.Name) == 0 {
+			return errors.New("%s: activity name is required", path)
+		}
+		if isActivity != nil && !isActivity(s.Activity.Name) {
+			return errors.New("%s: activity is not registered: %s", path, s.Activity.Name)
+		}
+	case KindSequence:
+		return validateSteps(s.Sequence, path+".sequence", isActivity)
+	case KindParallel:
+		return validateSteps(s.Parallel, path+".parallel", isActivity)
+	case KindChoice:
+		if len(s.Choice) == 0 {
+			return errors.New("%s: choice has no branches", path)
+		}
+		for i, branch := range s.Choice {
+			branchPath := fmt.Sprintf("%s.choice[%d]", path, i)
+			if branch.When != nil {
+				if err := branch.When.validate(branchPath); err != nil {
+					return err
+				}
+			} else if i != len(s.Choice)-1 {
+				return errors.New("%s: only the last branch can be the default branch (without when)", branchPath)
+			}
+			if err := validateSteps(branch.Steps, branchPath+".steps", isActivity); err != nil {
+				return err
+			}
+		}
+	case KindWaitSignal:
+		if len(s.WaitSignal.Name) == 0 {
+			return errors.New("%s: signal name is required", path)
+		}
+	case KindTimer:
+		if s.Timer.Duration <= 0 {
+			return errors.New("%s: timer duration must be positive", path)
+		}
+	}
+	return nil
+}
+
+func (c *Condition) validate(path string) error {
+	if len(c.Var) == 0 {
+		return errors.New("%s: when.var is required", path)
+	}
+	set := 0
+	for _, v := range []bool{c.Equals != nil, c.NotEquals != nil, c.Exists != nil} {
+		if v {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("%s: when must have exactly one of equals, not_equals or exists", path)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package dsl
+
+import (
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/workflow"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query types registered by the interpreter workflow
+const (
+	// QueryCurrentSteps returns the running steps ([]StepState - more than one inside parallel steps)
+	QueryCurrentSteps = "dsl_current_steps"
+
+	// QueryVariables returns the variables (map[string]interface{})
+	QueryVariables = "dsl_variables"
+)
+
+// StepState is a running step
+type StepState struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Attempt   int       `json:"attempt"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// interpreter runs one definition - it is created per workflow execution
+type interpreter struct {
+	def    *Definition
+	vars   map[string]interface{}
+	active map[string]StepState
+}
+
+// interpret is the interpreter workflow. It returns the variables at the end of the workflow.
+func (r *Registry) interpret(ctx workflow.Context, in Input) (map[string]interface{}, error) {
+	// The latest version is resolved once and recorded, so the replay uses the same version
+	if in.Version == 0 {
+		if err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+			def, err := r.Get(in.Name, 0)
+			if err != nil {
+				// Not a version - a replay fails the same way even if the definition is added later
+				return -1
+			}
+			return def.Version
+		}).Get(&in.Version); err != nil {
+			return nil, err
+		}
+	}
+	def, err := r.Get(in.Name, in.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &interpreter{def: def, vars: map[string]interface{}{}, active: map[string]StepState{}}
+	for k, v := range def.Variables {
+		it.vars[k] = v
+	}
+	for k, v := range in.Vars {
+		it.vars[k] = v
+	}
+
+	if err := workflow.SetQueryHandler(ctx, QueryCurrentSteps, func() ([]StepState, error) {
+		steps := make([]StepState, 0, len(it.active))
+		for _, s := range it.active {
+			steps = append(steps, s)
+		}
+		sort.Slice(steps, func(i, j int) bool { return steps[i].ID < steps[j].ID })
+		return steps, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryVariables, func() (map[string]interface{}, error) {
+		return it.vars, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, activityOptions(def.ActivityOptions))
+	if err := it.runSteps(ctx, def.Steps); err != nil {
+		return nil, err
+	}
+	return it.vars, nil
+}
+
+func activityOptions(o ActivityOptions) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		TaskList:               o.TaskList,
+		ScheduleToStartTimeout: o.ScheduleToStartTimeout,
+		StartToCloseTimeout:    o.StartToCloseTimeout,
+		HeartbeatTimeout:       o.HeartbeatTimeout,
+	}
+}
+
+func (it *interpreter) runSteps(ctx workflow.Context, steps []*Step) error {
+	for _, step := range steps {
+		if err := it.run(ctx, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the step with its retry policy
+func (it *interpreter) run(ctx workflow.Context, step *Step) error {
+	attempts, interval := 1, time.Duration(0)
+	if step.Retry != nil {
+		attempts, interval = step.Retry.MaxAttempts, step.Retry.InitialInterval
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		it.active[step.ID] = StepState{ID: step.ID, Kind: step.kind, Attempt: attempt, StartedAt: workflow.Now(ctx)}
+		err = it.runOnce(ctx, step)
+		delete(it.active, step.ID)
+		if err == nil || ctx.Err() != nil || attempt == attempts {
+			break
+		}
+
+		workflow.GetLogger(ctx).Warn(fmt.Sprintf("dsl step %s failed (attempt %d): %v", step.ID, attempt, err))
+		if interval > 0 {
+			if e := workflow.Sleep(ctx, interval); e != nil {
+				return e
+			}
+			interval = nextInterval(step.Retry, interval)
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "step %s failed", step.ID)
+	}
+	return nil
+}
+
+func nextInterval(policy *RetryPolicy, interval time.Duration) time.Duration {
+	coefficient := policy.BackoffCoefficient
+	if coefficient < 1 {
+		coefficient = 2
+	}
+	interval = time.Duration(float64(interval) * coefficient)
+	if policy.MaxInterval > 0 && interval > policy.MaxInterval {
+		interval = policy.MaxInterval
+	}
+	return interval
+}
+
+func (it *interpreter) runOnce(ctx workflow.Context, step *Step) error {
+	switch step.kind {
+	case KindActivity:
+		return it.runActivity(ctx, step.Activity)
+	case KindSequence:
+		return it.runSteps(ctx, step.Sequence)
+	case KindParallel:
+		return it.runParallel(ctx, step.Parallel)
+	case KindChoice:
+		for _, branch := range step.Choice {
+			if branch.When == nil || branch.When.evaluate(it.vars) {
+				return it.runSteps(ctx, branch.Steps)
+			}
+		}
+		return nil
+	case KindWaitSignal:
+		return it.waitSignal(ctx, step.WaitSignal)
+	case KindTimer:
+		return workflow.Sleep(ctx, step.Timer.Duration)
+	}
+	return errors.New("unknown step kind: %s", step.kind)
+}
+
+func (it *interpreter) runActivity(ctx workflow.Context, a *ActivityStep) error {
+	if a.Options != nil {
+		options := *a.Options
+		if len(options.TaskList) == 0 {
+			options.TaskList = it.def.ActivityOptions.TaskList
+		}
+		if options.ScheduleToStartTimeout == 0 {
+			options.ScheduleToStartTimeout = it.def.ActivityOptions.ScheduleToStartTimeout
+		}
+		if options.StartToCloseTimeout == 0 {
+			options.StartToCloseTimeout = it.def.ActivityOptions.StartToCloseTimeout
+		}
+		ctx = workflow.WithActivityOptions(ctx, activityOptions(options))
+	}
+
+	args := make([]interface{}, 0, len(a.Args))
+	for _, arg := range a.Args {
+		args = append(args, it.resolve(arg))
+	}
+
+	var result interface{}
+	if err := workflow.ExecuteActivity(ctx, a.Name, args...).Get(ctx, &result); err != nil {
+		return err
+	}
+	if len(a.Result) > 0 {
+		it.vars[a.Result] = result
+	}
+	return nil
+}
+
+// runParallel runs the steps at the same time. The other steps are cancelled when one fails.
+func (it *interpreter) runParallel(ctx workflow.Context, steps []*Step) error {
+	ctx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	wg := workflow.NewWaitGroup(ctx)
+	for _, step := range steps {
+		wg.Add(1)
+		workflow.Go(ctx, func(ctx workflow.Context) {
+			defer wg.Done()
+			if err := it.run(ctx, step); err != nil && firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		})
+	}
+	wg.Wait(ctx)
+	return firstErr
+}
+
+func (it *interpreter) waitSignal(ctx workflow.Context, s *SignalStep) error {
+	var value interface{}
+	received, timedOut := false, false
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, s.Name), func(c workflow.Channel, more bool) {
+		c.Receive(ctx, &value)
+		received = true
+	})
+	selector.AddReceive(ctx.Done(), func(c workflow.Channel, more bool) {})
+
+	if s.Timeout > 0 {
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		defer cancelTimer()
+		selector.AddFuture(workflow.NewTimer(timerCtx, s.Timeout), func(f workflow.Future) {
+			timedOut = f.Get(timerCtx, nil) == nil
+		})
+	}
+	selector.Select(ctx)
+
+	switch {
+	case received:
+		if len(s.Result) > 0 {
+			it.vars[s.Result] = value
+		}
+		return nil
+	case timedOut:
+		return errors.New("timed out waiting for signal %s", s.Name)
+	}
+	return ctx.Err()
+}
+
+// resolve replaces "$path" strings (also inside lists and maps) with the variable value
+func (it *interpreter) resolve(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case string:
+		if strings.HasPrefix(v, "$") {
+			value, _ := lookup(it.vars, v[1:])
+			return value
+		}
+		return v
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, it.resolve(item))
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = it.resolve(item)
+		}
+		return out
+	}
+	return arg
+}
+
+// lookup finds a dot separated path in the variables
+func lookup(vars map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = vars
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (c *Condition) evaluate(vars map[string]interface{}) bool {
+	value, found := lookup(vars, c.Var)
+	switch {
+	case c.Exists != nil:
+		return found == *c.Exists
+	case c.Equals != nil:
+		return found && fmt.Sprint(value) == fmt.Sprint(c.Equals)
+	default:
+		return !found || fmt.Sprint(value) != fmt.Sprint(c.NotEquals)
+	}
+}
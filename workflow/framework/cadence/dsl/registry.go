@@ -0,0 +1,198 @@
+package dsl
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-base/v2/serialization"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WorkflowName is the name of the interpreter workflow
+const WorkflowName = "gox.dsl.Interpreter"
+
+// Input is the input of the interpreter workflow
+type Input struct {
+	Name    string                 `json:"name"`
+	Version int                    `json:"version"`
+	Vars    map[string]interface{} `json:"vars,omitempty"`
+}
+
+// Registry keeps the definitions by name and version.
+//
+// Old versions must stay in the registry as long as workflows started with them are running - a running workflow
+// always uses the version it was started with.
+type Registry struct {
+	lock        sync.RWMutex
+	definitions map[string]map[int]*Definition
+	activities  map[string]bool
+	taskLists   map[string]bool
+}
+
+// RegistryOption configures the Registry
+type RegistryOption func(r *Registry)
+
+// WithTaskLists limits the task lists definitions can run on (e.g. the task lists of the worker groups which run the
+// interpreter workflow)
+func WithTaskLists(taskLists ...string) RegistryOption {
+	return func(r *Registry) {
+		for _, tl := range taskLists {
+			r.taskLists[tl] = true
+		}
+	}
+}
+
+// WithActivities adds activity names which definitions can use, e.g. activities registered by other workers. Use
+// RegisterActivity for activities of this process.
+func WithActivities(names ...string) RegistryOption {
+	return func(r *Registry) {
+		for _, name := range names {
+			r.activities[name] = true
+		}
+	}
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		definitions: map[string]map[int]*Definition{},
+		activities:  map[string]bool{},
+		taskLists:   map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RegisterActivity registers the activity with cadence under the name and allows definitions to use it
+func (r *Registry) RegisterActivity(name string, activityFunc interface{}) {
+	activity.RegisterWithOptions(activityFunc, activity.RegisterOptions{Name: name})
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.activities[name] = true
+}
+
+// Register registers the interpreter workflow with cadence
+func (r *Registry) Register() {
+	workflow.RegisterWithOptions(r.interpret, workflow.RegisterOptions{Name: WorkflowName})
+}
+
+// Add validates the definition and adds it. A definition with the same name and version can't be added twice.
+func (r *Registry) Add(def *Definition) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := def.Validate(func(name string) bool { return r.activities[name] }); err != nil {
+		return err
+	}
+	if len(r.taskLists) > 0 && !r.taskLists[def.TaskList] {
+		return errors.New("task list %s of definition %s is not configured", def.TaskList, def.Name)
+	}
+	if _, ok := r.definitions[def.Name][def.Version]; ok {
+		return errors.New("definition %s version %d is already added", def.Name, def.Version)
+	}
+
+	if r.definitions[def.Name] == nil {
+		r.definitions[def.Name] = map[int]*Definition{}
+	}
+	r.definitions[def.Name][def.Version] = def
+	return nil
+}
+
+// Parse parses a YAML or JSON definition
+func Parse(data []byte) (*Definition, error) {
+	def := &Definition{}
+	if err := serialization.ReadYamlFromString(string(data), def); err != nil {
+		return nil, errors.Wrap(err, "failed to parse workflow definition")
+	}
+	return def, nil
+}
+
+// LoadFile parses and adds a definition file
+func (r *Registry) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read workflow definition: %s", file)
+	}
+	def, err := Parse(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse workflow definition: %s", file)
+	}
+	return r.Add(def)
+}
+
+// LoadDir adds all *.yaml, *.yml and *.json files in the directory
+func (r *Registry) LoadDir(dir string) error {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return errors.Wrap(err, "failed to list workflow definitions: %s", dir)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if err := r.LoadFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the definition - version 0 returns the latest version
+func (r *Registry) Get(name string, version int) (*Definition, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	versions, ok := r.definitions[name]
+	if !ok {
+		return nil, errors.New("workflow definition not found: %s", name)
+	}
+	if version == 0 {
+		for v := range versions {
+			version = max(version, v)
+		}
+	}
+	def, ok := versions[version]
+	if !ok {
+		return nil, errors.New("workflow definition %s version %d not found", name, version)
+	}
+	return def, nil
+}
+
+// Versions returns the versions of a definition in ascending order
+func (r *Registry) Versions(name string) []int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	versions := make([]int, 0, len(r.definitions[name]))
+	for v := range r.definitions[name] {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Start starts the interpreter workflow for the definition (version 0 is the latest version). The task list and
+// timeouts are taken from the definition; a random workflow id is used if workflowID is empty.
+func (r *Registry) Start(ctx context.Context, api cadence.Api, name string, version int, workflowID string, vars map[string]interface{}) (*workflow.Execution, error) {
+	def, err := r.Get(name, version)
+	if err != nil {
+		return nil, err
+	}
+	options := client.StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        def.TaskList,
+		ExecutionStartToCloseTimeout:    def.ExecutionTimeout,
+		DecisionTaskStartToCloseTimeout: 10 * time.Second,
+	}
+	return api.StartWorkflow(ctx, options, WorkflowName, Input{Name: def.Name, Version: def.Version, Vars: vars})
+}
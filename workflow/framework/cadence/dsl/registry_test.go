@@ -0,0 +1,57 @@
+package dsl
+
+import (
+	"testing"
+)
+
+func TestRegistryVersions(t *testing.T) {
+	definition := func(version string) []byte {
+		return []byte(`
+name: onboarding
+` + version + `
+task_list: server_1_ts_1
+steps:
+  - timer: { duration: 1h }
+`)
+	}
+
+	r := NewRegistry()
+
+	// A definition without a version (version 0) is rejected - 0 is the latest version in Get
+	for _, version := range []string{"", "version: 0"} {
+		def, err := Parse(definition(version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Add(def); err == nil {
+			t.Fatalf("definition with %q must be rejected", version)
+		}
+	}
+
+	v1, err := Parse(definition("version: 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(v1); err != nil {
+		t.Fatal(err)
+	}
+	if def, err := r.Get("onboarding", 0); err != nil || def.Version != 1 {
+		t.Fatalf("latest version must be 1 - found %v, %v", def, err)
+	}
+
+	v2, err := Parse(definition("version: 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(v2); err != nil {
+		t.Fatal(err)
+	}
+	if def, err := r.Get("onboarding", 0); err != nil || def.Version != 2 {
+		t.Fatalf("latest version must be 2 - found %v, %v", def, err)
+	}
+
+	// A started version stays pinned after a newer version is added
+	if def, err := r.Get("onboarding", 1); err != nil || def != v1 {
+		t.Fatalf("version 1 must be pinned - found %v, %v", def, err)
+	}
+}
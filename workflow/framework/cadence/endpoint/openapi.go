@@ -0,0 +1,270 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Info is the info section of the OpenAPI document
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Document is a minimal OpenAPI 3 document - only the parts generated from the routes
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Components holds the schemas of the named Go types
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation is an OpenAPI operation
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a JSON response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the JSON schema of a Go type
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI builds the OpenAPI 3 document for the routes
+func (r *Router) OpenAPI(info Info) *Document {
+	g := &schemaGenerator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+	document := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      map[string]map[string]*Operation{},
+		Components: Components{Schemas: g.schemas},
+	}
+
+	started := g.schema(reflect.TypeOf(StartedResponse{}))
+	failed := g.schema(reflect.TypeOf(ErrorResponse{}))
+	for i := range r.routes {
+		route := &r.routes[i]
+		path, params := openAPIPath(route.Path)
+
+		op := &Operation{
+			OperationID: route.OperationID,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Tags:        route.Tags,
+			Responses: map[string]*Response{
+				"400": jsonResponse("bad request", failed),
+				"500": jsonResponse("failed to start or run the workflow", failed),
+			},
+		}
+		for _, p := range params {
+			op.Parameters = append(op.Parameters, &Parameter{Name: p, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		if route.Method != http.MethodGet && route.Method != http.MethodDelete {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: g.schema(route.inputType)}}}
+		}
+		if route.Mode == ModeSync {
+			op.Responses["200"] = jsonResponse("workflow result", g.schema(route.outputType))
+			op.Responses["202"] = jsonResponse("workflow started - result is not ready within the timeout", started)
+		} else {
+			op.Responses["202"] = jsonResponse("workflow started", started)
+			op.Responses["409"] = jsonResponse("workflow with the id is already running", failed)
+		}
+
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*Operation{}
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return document
+}
+
+func jsonResponse(description string, schema *Schema) *Response {
+	return &Response{Description: description, Content: map[string]*MediaType{"application/json": {Schema: schema}}}
+}
+
+// openAPIPath converts gin path params (":id", "*path") to OpenAPI params ("{id}")
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawJsonType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaGenerator builds schemas from Go types. Named structs are added to components and referenced with $ref.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawJsonType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if len(s.Ref) == 0 {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		switch {
+		case len(t.Name()) == 0:
+			return g.structSchema(t)
+		case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+			// Custom JSON - we don't know the shape
+			return &Schema{}
+		}
+		return g.ref(t)
+	default:
+		// interface{} and anything we can't describe
+		return &Schema{}
+	}
+}
+
+// ref adds the named struct to components (once) and returns a reference to it
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			pkg := t.PkgPath()
+			name = strings.ReplaceAll(pkg[strings.LastIndex(pkg, "/")+1:], ".", "_") + "_" + name
+		}
+		g.names[t] = name
+
+		// Add a placeholder first so recursive types end in a $ref
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema builds the object schema using the same rules as encoding/json (json tags, embedded structs)
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && len(name) == 0 {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for k, v := range embedded.Properties {
+					if _, ok := s.Properties[k]; !ok {
+						s.Properties[k] = v
+					}
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+		fs := g.schema(field.Type)
+		if strings.Contains(opts, "string") && len(fs.Ref) == 0 {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
@@ -0,0 +1,370 @@
+// Package endpoint exposes workflows as HTTP endpoints. Routes are declared with the workflow to start, the task list,
+// a workflow id template and the mode (async or sync). The gin routes and an OpenAPI 3 document are generated from the
+// routes and their Go input/output types.
+//
+//	router, err := endpoint.NewRouter(workflowApi,
+//		endpoint.FromWorkflowDef(orderWorkflow, endpoint.Route{
+//			Method:     http.MethodPost,
+//			Path:       "/api/v1/orders",
+//			TaskList:   "server_1_ts_1",
+//			IDTemplate: "order-{{.Input.OrderID}}",
+//			Mode:       endpoint.ModeSync,
+//			QueryType:  "status",
+//			Timeout:    20 * time.Second,
+//		}),
+//	)
+//	router.Register(ginRouter)
+//	router.RegisterOpenAPI(ginRouter, "/openapi.json", endpoint.Info{Title: "Orders", Version: "1.0"})
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"net/http"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// ModeAsync starts the workflow and returns 202 with the workflow id (default). 409 is returned if a workflow with
+	// the id (see IDTemplate) is already running.
+	ModeAsync = "async"
+
+	// ModeSync starts the workflow and waits for the result (or the query result if QueryType is set) until Timeout.
+	// 200 is returned with the result, or 202 with status PROCESSING if the result is not ready in time. If a workflow
+	// with the id is already running, the request waits for that workflow.
+	ModeSync = "sync"
+
+	StatusStarted    = "STARTED"
+	StatusProcessing = "PROCESSING"
+
+	defaultTimeout          = 30 * time.Second
+	defaultPollInterval     = time.Second
+	defaultExecutionTimeout = time.Hour
+	defaultDecisionTimeout  = 10 * time.Second
+)
+
+// Route maps an HTTP route to a workflow
+type Route struct {
+	// Method is the HTTP method (default POST)
+	Method string
+
+	// Path is the gin path e.g. /api/v1/orders/:id
+	Path string
+
+	// OperationID is the unique operationId in the OpenAPI document (default is made from the method and the path e.g.
+	// "post_api_v1_orders_id")
+	OperationID string
+
+	// Summary and Description are used in the OpenAPI document
+	Summary     string
+	Description string
+	Tags        []string
+
+	WorkflowType string
+	TaskList     string
+
+	// IDTemplate is a text/template for the workflow id. The template data has Input (request body), Params (path
+	// params) and Query (query params) e.g. "order-{{.Input.OrderID}}" or "user-{{.Params.id}}". A random id is used
+	// if it is empty.
+	IDTemplate string
+
+	// Mode is "async" (default) or "sync"
+	Mode string
+
+	// Timeout is the max time to wait for the result in sync mode (default 30s)
+	Timeout time.Duration
+
+	// QueryType if set is polled in sync mode to get the result (every PollInterval, default 1s) instead of waiting for
+	// the workflow to complete - see example/cadence/rest
+	QueryType    string
+	PollInterval time.Duration
+
+	// ExecutionTimeout (default 1h) and DecisionTimeout (default 10s) are used to start the workflow
+	ExecutionTimeout time.Duration
+	DecisionTimeout  time.Duration
+
+	inputType  reflect.Type
+	outputType reflect.Type
+	idTemplate *template.Template
+}
+
+// New sets the input and output types of the route - they are used to read the request body and to generate the
+// OpenAPI document
+func New[In, Out any](route Route) Route {
+	route.inputType = reflect.TypeOf((*In)(nil)).Elem()
+	route.outputType = reflect.TypeOf((*Out)(nil)).Elem()
+	return route
+}
+
+// FromWorkflowDef creates the route for a typed workflow - workflow type and input/output types are taken from it
+func FromWorkflowDef[In, Out any](def cadence.WorkflowDef[In, Out], route Route) Route {
+	route.WorkflowType = def.Name()
+	return New[In, Out](route)
+}
+
+// StartedResponse is returned (202) when the workflow is started but the result is not returned
+type StartedResponse struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Status     string `json:"status"`
+}
+
+// ErrorResponse is returned on errors
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// idTemplateData is the data given to the id template
+type idTemplateData struct {
+	Input  interface{}
+	Params map[string]string
+	Query  map[string]string
+}
+
+func (r *Route) init() error {
+	if len(r.Method) == 0 {
+		r.Method = http.MethodPost
+	}
+	r.Method = strings.ToUpper(r.Method)
+	if len(r.Mode) == 0 {
+		r.Mode = ModeAsync
+	}
+	if r.Timeout == 0 {
+		r.Timeout = defaultTimeout
+	}
+	if r.PollInterval == 0 {
+		r.PollInterval = defaultPollInterval
+	}
+	if r.ExecutionTimeout == 0 {
+		r.ExecutionTimeout = defaultExecutionTimeout
+	}
+	if r.DecisionTimeout == 0 {
+		r.DecisionTimeout = defaultDecisionTimeout
+	}
+	if len(r.OperationID) == 0 {
+		r.OperationID = operationID(r.Method, r.Path)
+	}
+
+	switch {
+	case len(r.Path) == 0:
+		return errors.New("path is required")
+	case len(r.WorkflowType) == 0:
+		return errors.New("workflow type is required for route %s %s", r.Method, r.Path)
+	case len(r.TaskList) == 0:
+		return errors.New("task list is required for route %s %s", r.Method, r.Path)
+	case r.Mode != ModeAsync && r.Mode != ModeSync:
+		return errors.New("mode must be %s or %s for route %s %s", ModeAsync, ModeSync, r.Method, r.Path)
+	case r.inputType == nil:
+		return errors.New("input/output types are not set for route %s %s - use endpoint.New or endpoint.FromWorkflowDef", r.Method, r.Path)
+	}
+
+	if len(r.IDTemplate) > 0 {
+		t, err := template.New(r.Path).Option("missingkey=error").Parse(r.IDTemplate)
+		if err != nil {
+			return errors.Wrap(err, "bad id template for route %s %s", r.Method, r.Path)
+		}
+		r.idTemplate = t
+	}
+	return nil
+}
+
+// Router creates the gin routes for the workflow routes
+type Router struct {
+	api    cadence.Api
+	routes []Route
+}
+
+// NewRouter validates the routes and creates a router
+func NewRouter(api cadence.Api, routes ...Route) (*Router, error) {
+	r := &Router{api: api}
+	operationIDs := map[string]bool{}
+	for _, route := range routes {
+		if err := route.init(); err != nil {
+			return nil, err
+		}
+		if operationIDs[route.OperationID] {
+			return nil, errors.New("operation id %s of route %s %s is used by another route", route.OperationID, route.Method, route.Path)
+		}
+		operationIDs[route.OperationID] = true
+		r.routes = append(r.routes, route)
+	}
+	return r, nil
+}
+
+// operationID makes the OpenAPI operationId from the method and the gin path e.g. POST /api/v1/orders/:id is
+// "post_api_v1_orders_id"
+func operationID(method string, path string) string {
+	id := []rune(strings.ToLower(method))
+	for _, c := range path {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			id = append(id, c)
+		case c == ':' || c == '*':
+		case id[len(id)-1] != '_':
+			id = append(id, '_')
+		}
+	}
+	return strings.TrimRight(string(id), "_")
+}
+
+// Register registers all the routes on the gin router
+func (r *Router) Register(router gin.IRouter) {
+	for i := range r.routes {
+		route := &r.routes[i]
+		router.Handle(route.Method, route.Path, r.handler(route))
+	}
+}
+
+// RegisterOpenAPI serves the OpenAPI document on the path
+func (r *Router) RegisterOpenAPI(router gin.IRouter, path string, info Info) {
+	document := r.OpenAPI(info)
+	router.GET(path, func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	})
+}
+
+func (r *Router) handler(route *Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input := reflect.New(route.inputType)
+		if c.Request.ContentLength != 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			if err := c.ShouldBindJSON(input.Interface()); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+
+		id, err := route.workflowID(c, input.Elem().Interface())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		options := client.StartWorkflowOptions{
+			ID:                              id,
+			TaskList:                        route.TaskList,
+			ExecutionStartToCloseTimeout:    route.ExecutionTimeout,
+			DecisionTaskStartToCloseTimeout: route.DecisionTimeout,
+		}
+		execution, err := r.api.StartWorkflow(c.Request.Context(), options, route.WorkflowType, input.Elem().Interface())
+		var alreadyStarted *shared.WorkflowExecutionAlreadyStartedError
+		if err != nil && route.Mode == ModeSync && errors.As(err, &alreadyStarted) {
+			// A duplicate request waits for the running workflow
+			execution, err = &workflow.Execution{ID: id, RunID: alreadyStarted.GetRunId()}, nil
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		started := StartedResponse{WorkflowID: execution.ID, RunID: execution.RunID, Status: StatusStarted}
+		if route.Mode == ModeAsync {
+			c.JSON(http.StatusAccepted, started)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), route.Timeout)
+		defer cancel()
+		ctx = context.WithValue(ctx, cadence.TaskListForAction, route.TaskList)
+
+		result := reflect.New(route.outputType)
+		if len(route.QueryType) > 0 {
+			err = r.pollQuery(ctx, route, execution.ID, execution.RunID, result.Interface())
+		} else {
+			err = r.waitForResult(ctx, execution.ID, execution.RunID, result.Interface())
+		}
+
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, result.Elem().Interface())
+		case ctx.Err() != nil:
+			started.Status = StatusProcessing
+			c.JSON(http.StatusAccepted, started)
+		default:
+			c.JSON(errorStatus(err), ErrorResponse{Error: err.Error()})
+		}
+	}
+}
+
+// errorStatus maps the errors of the cadence calls to http status
+func errorStatus(err error) int {
+	var notExists *shared.EntityNotExistsError
+	var badRequest *shared.BadRequestError
+	var alreadyStarted *shared.WorkflowExecutionAlreadyStartedError
+	var queryFailed *shared.QueryFailedError
+	var limitExceeded *shared.LimitExceededError
+	switch {
+	case errors.As(err, &notExists):
+		return http.StatusNotFound
+	case errors.As(err, &badRequest), errors.As(err, &queryFailed):
+		return http.StatusBadRequest
+	case errors.As(err, &alreadyStarted):
+		return http.StatusConflict
+	case errors.As(err, &limitExceeded):
+		return http.StatusTooManyRequests
+	case strings.Contains(err.Error(), "task list not registered"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// workflowID executes the id template
+func (route *Route) workflowID(c *gin.Context, input interface{}) (string, error) {
+	if route.idTemplate == nil {
+		return uuid.NewString(), nil
+	}
+
+	data := idTemplateData{Input: input, Params: map[string]string{}, Query: map[string]string{}}
+	for _, p := range c.Params {
+		data.Params[p.Key] = p.Value
+	}
+	for k := range c.Request.URL.Query() {
+		data.Query[k] = c.Query(k)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := route.idTemplate.Execute(buf, data); err != nil {
+		return "", errors.Wrap(err, "failed to build workflow id")
+	}
+	if buf.Len() == 0 {
+		return "", errors.New("workflow id is empty")
+	}
+	return buf.String(), nil
+}
+
+// waitForResult waits for the workflow to complete
+func (r *Router) waitForResult(ctx context.Context, workflowID string, runID string, result interface{}) error {
+	run, err := r.api.GetWorkflow(ctx, workflowID, runID)
+	if err != nil {
+		return err
+	}
+	return run.Get(ctx, result)
+}
+
+// pollQuery queries the workflow until the query returns a result (query returns error if result is not ready)
+func (r *Router) pollQuery(ctx context.Context, route *Route, workflowID string, runID string, result interface{}) error {
+	ticker := time.NewTicker(route.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if value, err := r.api.QueryWorkflow(ctx, workflowID, runID, route.QueryType); err == nil && value != nil && value.HasValue() {
+				if err := value.Get(result); err == nil {
+					return nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// WrapApi returns an Api which emits events for the calls which change a workflow: workflow started (start and
+// execute), cancel requested and terminated
+func WrapApi(api cadence.Api, emitter *Emitter) cadence.Api {
+	return &eventApi{Api: api, emitter: emitter}
+}
+
+type eventApi struct {
+	cadence.Api
+	emitter *Emitter
+}
+
+func (a *eventApi) StartWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflowFunc interface{}, args ...interface{}) (*workflow.Execution, error) {
+	execution, err := a.Api.StartWorkflow(ctx, options, workflowFunc, args...)
+	if err == nil {
+		a.emitStarted(options, workflowFunc, execution.ID, execution.RunID)
+	}
+	return execution, err
+}
+
+func (a *eventApi) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflowFunc interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	run, err := a.Api.ExecuteWorkflow(ctx, options, workflowFunc, args...)
+	if err == nil {
+		a.emitStarted(options, workflowFunc, run.GetID(), run.GetRunID())
+	}
+	return run, err
+}
+
+func (a *eventApi) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	err := a.Api.CancelWorkflow(ctx, workflowID, runID)
+	if err == nil {
+		a.emitter.Emit(Event{Type: WorkflowCancelRequested, Source: SourceClient, TaskList: taskList(ctx), WorkflowID: workflowID, RunID: runID})
+	}
+	return err
+}
+
+func (a *eventApi) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error {
+	err := a.Api.TerminateWorkflow(ctx, workflowID, runID, reason, details)
+	if err == nil {
+		a.emitter.Emit(Event{Type: WorkflowTerminated, Source: SourceClient, TaskList: taskList(ctx), WorkflowID: workflowID, RunID: runID, Reason: reason})
+	}
+	return err
+}
+
+func (a *eventApi) emitStarted(options client.StartWorkflowOptions, workflowFunc interface{}, workflowID string, runID string) {
+	a.emitter.Emit(Event{
+		Type:         WorkflowStarted,
+		Source:       SourceClient,
+		TaskList:     options.TaskList,
+		WorkflowType: workflowTypeName(workflowFunc),
+		WorkflowID:   workflowID,
+		RunID:        runID,
+	})
+}
+
+func taskList(ctx context.Context) string {
+	tl, _ := ctx.Value(cadence.TaskListForAction).(string)
+	return tl
+}
+
+// workflowTypeName returns the name cadence uses for the workflow (name or function)
+func workflowTypeName(workflowFunc interface{}) string {
+	if name, ok := workflowFunc.(string); ok {
+		return name
+	}
+	if v := reflect.ValueOf(workflowFunc); v.Kind() == reflect.Func {
+		return strings.TrimSuffix(runtime.FuncForPC(v.Pointer()).Name(), "-fm")
+	}
+	return ""
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/uber-go/tally"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Emitter buffers events and writes them to the sink in batches from a background goroutine.
+//
+// When the buffer is full the event is dropped (default) or Emit blocks until there is space (WithBlockOnFull). Both
+// are reported in the metrics: gox_events_emitted, gox_events_dropped, gox_events_blocked, gox_events_published,
+// gox_events_publish_failed, gox_events_buffered (gauge) and gox_events_publish_latency.
+type Emitter struct {
+	sink          Sink
+	buffer        chan Event
+	batchSize     int
+	flushInterval time.Duration
+	blockOnFull   bool
+	maxAttempts   int
+	scope         tally.Scope
+	logger        *slog.Logger
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// EmitterOption configures the Emitter
+type EmitterOption func(e *Emitter)
+
+// WithBufferSize sets the max events buffered in memory (default 10000)
+func WithBufferSize(size int) EmitterOption {
+	return func(e *Emitter) {
+		e.buffer = make(chan Event, size)
+	}
+}
+
+// WithBatchSize sets the max events written to the sink in one call (default 100)
+func WithBatchSize(size int) EmitterOption {
+	return func(e *Emitter) {
+		e.batchSize = size
+	}
+}
+
+// WithFlushInterval sets the max time an event waits in the buffer before it is written (default 1s)
+func WithFlushInterval(interval time.Duration) EmitterOption {
+	return func(e *Emitter) {
+		e.flushInterval = interval
+	}
+}
+
+// WithBlockOnFull makes Emit wait when the buffer is full instead of dropping the event. Use it only if losing
+// events is worse than slowing down workflows and activities.
+func WithBlockOnFull() EmitterOption {
+	return func(e *Emitter) {
+		e.blockOnFull = true
+	}
+}
+
+// WithMaxAttempts sets the attempts to write a batch before it is dropped (default 3)
+func WithMaxAttempts(attempts int) EmitterOption {
+	return func(e *Emitter) {
+		e.maxAttempts = attempts
+	}
+}
+
+// WithMetricScope sets the metric scope (default no metrics)
+func WithMetricScope(scope tally.Scope) EmitterOption {
+	return func(e *Emitter) {
+		e.scope = scope
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger *slog.Logger) EmitterOption {
+	return func(e *Emitter) {
+		e.logger = logger
+	}
+}
+
+// NewEmitter creates an emitter and starts the background writer. Call Close to flush the buffered events.
+func NewEmitter(sink Sink, opts ...EmitterOption) *Emitter {
+	e := &Emitter{
+		sink:          sink,
+		buffer:        make(chan Event, 10000),
+		batchSize:     100,
+		flushInterval: time.Second,
+		maxAttempts:   3,
+		scope:         tally.NoopScope,
+		logger:        slog.Default(),
+		closed:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = 1
+	}
+	if e.maxAttempts <= 0 {
+		e.maxAttempts = 1
+	}
+
+	go e.run()
+	return e
+}
+
+// Emit adds the event to the buffer. ID and Time are set if they are empty. Events emitted after Close are dropped.
+func (e *Emitter) Emit(event Event) {
+	if len(event.ID) == 0 {
+		event.ID = uuid.NewString()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	select {
+	case <-e.closed:
+		e.scope.Counter("gox_events_dropped").Inc(1)
+		return
+	default:
+	}
+
+	select {
+	case e.buffer <- event:
+		e.scope.Counter("gox_events_emitted").Inc(1)
+		return
+	default:
+	}
+
+	if !e.blockOnFull {
+		e.scope.Counter("gox_events_dropped").Inc(1)
+		return
+	}
+
+	e.scope.Counter("gox_events_blocked").Inc(1)
+	select {
+	case e.buffer <- event:
+		e.scope.Counter("gox_events_emitted").Inc(1)
+	case <-e.closed:
+		e.scope.Counter("gox_events_dropped").Inc(1)
+	}
+}
+
+// Close stops accepting events and writes the buffered events to the sink. It returns when all events are written
+// or the context is done.
+func (e *Emitter) Close(ctx context.Context) error {
+	e.closeOnce.Do(func() {
+		close(e.closed)
+	})
+	select {
+	case <-e.done:
+		if closer, ok := e.sink.(interface{ Close() error }); ok {
+			return closer.Close()
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run reads the buffer and writes batches when the batch is full or the flush interval passes
+func (e *Emitter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, e.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.write(batch)
+			batch = make([]Event, 0, e.batchSize)
+		}
+		e.scope.Gauge("gox_events_buffered").Update(float64(len(e.buffer)))
+	}
+
+	for {
+		select {
+		case event := <-e.buffer:
+			batch = append(batch, event)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.closed:
+			// Drain what is already buffered - Emit does not add events after close
+			for {
+				select {
+				case event := <-e.buffer:
+					batch = append(batch, event)
+					if len(batch) >= e.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write writes a batch to the sink with retries. The batch is dropped if all the attempts fail.
+func (e *Emitter) write(batch []Event) {
+	var err error
+	for attempt := 1; attempt <= e.maxAttempts; attempt++ {
+		start := time.Now()
+		err = e.sink.Write(context.Background(), batch)
+		e.scope.Timer("gox_events_publish_latency").Record(time.Since(start))
+		if err == nil {
+			e.scope.Counter("gox_events_published").Inc(int64(len(batch)))
+			return
+		}
+		if attempt < e.maxAttempts {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+	e.scope.Counter("gox_events_publish_failed").Inc(int64(len(batch)))
+	e.logger.Error("failed to write events to sink - events dropped", slog.Int("count", len(batch)), slog.String("error", err.Error()))
+}
@@ -0,0 +1,65 @@
+// Package events emits workflow and activity lifecycle events (started, completed, failed, timed out, cancelled and
+// terminated) to pluggable sinks e.g. an analytics pipeline.
+//
+// Events are produced by a client interceptor (WrapApi) and worker interceptors (ActivityInterceptor and
+// WorkflowInterceptorFactory). The Emitter buffers them and writes them to the sink in batches.
+//
+//	emitter := events.NewEmitter(events.NewProducerSink(producer, "workflow-events"), events.WithMetricScope(scope))
+//	defer emitter.Close(context.Background())
+//
+//	api, err := cadence.NewCadenceClient(cf, config, cadence.WithWorkerInterceptors(cadence.WorkerInterceptors{
+//		Workflow: []interceptors.WorkflowInterceptorFactory{emitter.WorkflowInterceptorFactory()},
+//		Activity: []cadence.ActivityInterceptor{emitter.ActivityInterceptor()},
+//	}))
+//	api = events.WrapApi(api, emitter)
+//
+// Workflow timeouts are not seen by the client or the worker, so there is no workflow timed out event.
+package events
+
+import (
+	"time"
+)
+
+// Event types
+const (
+	WorkflowStarted    = "workflow.started"
+	WorkflowCompleted  = "workflow.completed"
+	WorkflowFailed     = "workflow.failed"
+	WorkflowCancelled  = "workflow.cancelled"
+	WorkflowTerminated = "workflow.terminated"
+
+	// WorkflowCancelRequested is emitted by the client when a workflow is cancelled. The worker emits
+	// WorkflowCancelled when the workflow returns.
+	WorkflowCancelRequested = "workflow.cancel_requested"
+
+	ActivityStarted   = "activity.started"
+	ActivityCompleted = "activity.completed"
+	ActivityFailed    = "activity.failed"
+	ActivityTimedOut  = "activity.timed_out"
+	ActivityCancelled = "activity.cancelled"
+)
+
+// Event sources
+const (
+	SourceClient = "client"
+	SourceWorker = "worker"
+)
+
+// Event is a lifecycle event of a workflow or activity
+type Event struct {
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	Source       string        `json:"source"`
+	Time         time.Time     `json:"time"`
+	Domain       string        `json:"domain,omitempty"`
+	TaskList     string        `json:"task_list,omitempty"`
+	WorkflowType string        `json:"workflow_type,omitempty"`
+	WorkflowID   string        `json:"workflow_id"`
+	RunID        string        `json:"run_id,omitempty"`
+	ActivityType string        `json:"activity_type,omitempty"`
+	ActivityID   string        `json:"activity_id,omitempty"`
+	Attempt      int32         `json:"attempt,omitempty"`
+	Duration     time.Duration `json:"duration,omitempty"`
+	Reason       string        `json:"reason,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
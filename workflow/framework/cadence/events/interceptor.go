@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	gocadence "go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/interceptors"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+// ActivityInterceptor emits activity started, completed, failed, timed out and cancelled events
+func (e *Emitter) ActivityInterceptor() cadence.ActivityInterceptor {
+	return cadence.ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next cadence.ActivityHandler) (interface{}, error) {
+		info := activity.GetInfo(ctx)
+		event := Event{
+			Source:       SourceWorker,
+			Domain:       info.WorkflowDomain,
+			TaskList:     info.TaskList,
+			WorkflowType: info.WorkflowType.Name,
+			WorkflowID:   info.WorkflowExecution.ID,
+			RunID:        info.WorkflowExecution.RunID,
+			ActivityType: activityType,
+			ActivityID:   info.ActivityID,
+			Attempt:      info.Attempt,
+		}
+		started := event
+		started.Type = ActivityStarted
+		e.Emit(started)
+
+		start := time.Now()
+		result, err := next(ctx, args)
+		event.Duration = time.Since(start)
+		switch {
+		case err == nil:
+			event.Type = ActivityCompleted
+		case ctx.Err() == context.DeadlineExceeded:
+			event.Type = ActivityTimedOut
+			event.Error = err.Error()
+		case ctx.Err() == context.Canceled:
+			event.Type = ActivityCancelled
+			event.Error = err.Error()
+		default:
+			event.Type = ActivityFailed
+			event.Error = err.Error()
+		}
+		e.Emit(event)
+		return result, err
+	})
+}
+
+// WorkflowInterceptorFactory emits workflow started, completed, failed and cancelled events from the worker. Nothing
+// is emitted while the workflow is replaying.
+func (e *Emitter) WorkflowInterceptorFactory() interceptors.WorkflowInterceptorFactory {
+	return &workflowInterceptorFactory{emitter: e}
+}
+
+type workflowInterceptorFactory struct {
+	emitter *Emitter
+}
+
+func (f *workflowInterceptorFactory) NewInterceptor(info *workflow.Info, next interceptors.WorkflowInterceptor) interceptors.WorkflowInterceptor {
+	return &workflowInterceptor{WorkflowInterceptorBase: interceptors.WorkflowInterceptorBase{Next: next}, emitter: f.emitter, info: info}
+}
+
+type workflowInterceptor struct {
+	interceptors.WorkflowInterceptorBase
+	emitter *Emitter
+	info    *workflow.Info
+}
+
+func (i *workflowInterceptor) ExecuteWorkflow(ctx workflow.Context, workflowType string, args ...interface{}) []interface{} {
+	event := Event{
+		Source:       SourceWorker,
+		Domain:       i.info.Domain,
+		TaskList:     i.info.TaskListName,
+		WorkflowType: workflowType,
+		WorkflowID:   i.info.WorkflowExecution.ID,
+		RunID:        i.info.WorkflowExecution.RunID,
+		Attempt:      i.info.Attempt,
+	}
+	start := i.Next.Now(ctx)
+	if !i.Next.IsReplaying(ctx) {
+		started := event
+		started.Type = WorkflowStarted
+		started.Time = start
+		i.emitter.Emit(started)
+	}
+
+	result := i.Next.ExecuteWorkflow(ctx, workflowType, args...)
+
+	if !i.Next.IsReplaying(ctx) {
+		var err error
+		if len(result) > 0 {
+			err, _ = result[len(result)-1].(error)
+		}
+
+		var continueAsNew *workflow.ContinueAsNewError
+		switch {
+		case err == nil:
+			event.Type = WorkflowCompleted
+		case errors.As(err, &continueAsNew):
+			event.Type = WorkflowCompleted
+			event.Reason = "continued as new"
+		case gocadence.IsCanceledError(err):
+			event.Type = WorkflowCancelled
+			event.Error = err.Error()
+		default:
+			event.Type = WorkflowFailed
+			event.Error = err.Error()
+		}
+		event.Time = i.Next.Now(ctx)
+		event.Duration = event.Time.Sub(start)
+		i.emitter.Emit(event)
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink writes a batch of events
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// SinkFunc is a function adapter for Sink
+type SinkFunc func(ctx context.Context, events []Event) error
+
+func (f SinkFunc) Write(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// writerSink writes events as JSON lines
+type writerSink struct {
+	lock   sync.Mutex
+	writer *bufio.Writer
+	closer io.Closer
+}
+
+// NewWriterSink writes events as JSON lines e.g. NewWriterSink(os.Stdout)
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{writer: bufio.NewWriter(w)}
+}
+
+// NewFileSink appends events as JSON lines to the file. The file is closed by Emitter.Close.
+func NewFileSink(file string) (Sink, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open events file: %s", file)
+	}
+	return &writerSink{writer: bufio.NewWriter(f), closer: f}, nil
+}
+
+func (s *writerSink) Write(ctx context.Context, events []Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	encoder := json.NewEncoder(s.writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return errors.Wrap(err, "failed to write event")
+		}
+	}
+	return s.writer.Flush()
+}
+
+func (s *writerSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// NewChannelSink sends events to the channel (in process consumers). It blocks while the channel is full, which
+// fills the emitter buffer - see WithBlockOnFull.
+func NewChannelSink(ch chan<- Event) Sink {
+	return SinkFunc(func(ctx context.Context, events []Event) error {
+		for _, event := range events {
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+// Message is a message sent to a Producer
+type Message struct {
+	Key   []byte
+	Value []byte
+}
+
+// Producer is a Kafka style producer. Implement it with the kafka client used by the application; MemoryBroker is
+// an in-memory implementation for local runs.
+type Producer interface {
+	Produce(ctx context.Context, topic string, messages []Message) error
+}
+
+// NewProducerSink sends events as JSON messages to the topic. The workflow id is the message key, so all events of
+// a workflow go to the same partition in order.
+func NewProducerSink(producer Producer, topic string) Sink {
+	return SinkFunc(func(ctx context.Context, events []Event) error {
+		messages := make([]Message, 0, len(events))
+		for _, event := range events {
+			value, err := json.Marshal(event)
+			if err != nil {
+				return errors.Wrap(err, "failed to serialize event")
+			}
+			messages = append(messages, Message{Key: []byte(event.WorkflowID), Value: value})
+		}
+		return producer.Produce(ctx, topic, messages)
+	})
+}
+
+// MemoryBroker is an in-memory Producer which keeps the messages of every topic. It stands in for a real broker in
+// local runs and tests.
+type MemoryBroker struct {
+	lock   sync.Mutex
+	topics map[string][]Message
+}
+
+// NewMemoryBroker creates an in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: map[string][]Message{}}
+}
+
+func (b *MemoryBroker) Produce(ctx context.Context, topic string, messages []Message) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.topics[topic] = append(b.topics[topic], messages...)
+	return nil
+}
+
+// Messages returns the messages of the topic starting at offset
+func (b *MemoryBroker) Messages(topic string, offset int) []Message {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	messages := b.topics[topic]
+	if offset >= len(messages) {
+		return nil
+	}
+	return append([]Message(nil), messages[offset:]...)
+}
@@ -0,0 +1,203 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/interceptors"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ActivityHandler executes an activity (or the next interceptor in the chain) with the given arguments
+type ActivityHandler func(ctx context.Context, args []interface{}) (interface{}, error)
+
+// ActivityInterceptor wraps the execution of an activity on the worker side.
+// The interceptor must call next to continue the execution of the activity.
+type ActivityInterceptor interface {
+	InterceptActivity(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error)
+}
+
+// ActivityInterceptorFunc is a function adapter for ActivityInterceptor
+type ActivityInterceptorFunc func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error)
+
+func (f ActivityInterceptorFunc) InterceptActivity(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error) {
+	return f(ctx, activityType, args, next)
+}
+
+// WorkerInterceptors is the set of interceptors applied to the workers of a task list.
+//
+// Workflow interceptors are given to cadence as worker.Options.WorkflowInterceptorChainFactories, so they apply to
+// all workflows executed by the worker.
+//
+// Activity interceptors only apply to activities registered with RegisterActivity or RegisterActivityWithOptions
+// from this package. Activities registered directly with activity.Register are executed as is.
+type WorkerInterceptors struct {
+	Workflow []interceptors.WorkflowInterceptorFactory
+	Activity []ActivityInterceptor
+}
+
+// WithWorkerInterceptors sets the interceptors which are applied to all workers in all worker groups
+func WithWorkerInterceptors(wi WorkerInterceptors) Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		wrapper.interceptors = wi
+	}
+}
+
+// WithTaskListInterceptors overrides the interceptors for a single task list. The interceptors given here replace
+// the ones set with WithWorkerInterceptors for this task list.
+func WithTaskListInterceptors(taskList string, wi WorkerInterceptors) Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		if wrapper.taskListInterceptors == nil {
+			wrapper.taskListInterceptors = map[string]WorkerInterceptors{}
+		}
+		wrapper.taskListInterceptors[taskList] = wi
+	}
+}
+
+// interceptorsForTaskList returns the interceptors to be used for the given task list
+func (wrapper *cadenceWrapperImpl) interceptorsForTaskList(taskList string) WorkerInterceptors {
+	if wi, ok := wrapper.taskListInterceptors[taskList]; ok {
+		return wi
+	}
+	return wrapper.interceptors
+}
+
+type activityInterceptorsContextKey struct{}
+
+// withActivityInterceptors puts activity interceptors in the background activity context of a worker. Activities
+// registered with RegisterActivity pick them from the context when they execute.
+func withActivityInterceptors(ctx context.Context, activityInterceptors []ActivityInterceptor) context.Context {
+	if len(activityInterceptors) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, activityInterceptorsContextKey{}, activityInterceptors)
+}
+
+// RegisterActivity registers an activity (same as activity.Register) in a way that the activity interceptors set
+// with WithWorkerInterceptors or WithTaskListInterceptors are applied when it is executed.
+func RegisterActivity(activityFunc interface{}) {
+	RegisterActivityWithOptions(activityFunc, activity.RegisterOptions{})
+}
+
+// RegisterActivityWithOptions is same as RegisterActivity with options (see activity.RegisterWithOptions)
+//
+// Note - only activity functions are supported. A pointer to a struct is registered as is without interceptors.
+func RegisterActivityWithOptions(activityFunc interface{}, opts activity.RegisterOptions) {
+	fnType := reflect.TypeOf(activityFunc)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumIn() == 0 || fnType.In(0) != contextType {
+		activity.RegisterWithOptions(activityFunc, opts)
+		return
+	}
+
+	// Register the wrapped function with the same name which cadence would have used for the original function
+	if len(opts.Name) == 0 {
+		opts.Name = functionName(activityFunc)
+		if opts.EnableShortName {
+			elements := strings.Split(opts.Name, ".")
+			opts.Name = elements[len(elements)-1]
+		}
+	}
+	activity.RegisterWithOptions(wrapActivityFunction(opts.Name, activityFunc), opts)
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// functionName returns the name cadence uses for a function (see getFunctionName in cadence internal package)
+func functionName(fn interface{}) string {
+	fullName := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return strings.TrimSuffix(fullName, "-fm")
+}
+
+// wrapActivityFunction creates a function with the same signature as the activity function which runs the
+// activity interceptors found in the context before calling the activity function. The variadic arguments of a
+// variadic function are given to the interceptors as one slice argument.
+func wrapActivityFunction(activityType string, activityFunc interface{}) interface{} {
+	fnValue := reflect.ValueOf(activityFunc)
+	fnType := fnValue.Type()
+
+	// MakeFunc gives the variadic arguments as a slice - it must be passed on with CallSlice
+	call := fnValue.Call
+	if fnType.IsVariadic() {
+		call = fnValue.CallSlice
+	}
+
+	return reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		ctx := in[0].Interface().(context.Context)
+		activityInterceptors, _ := ctx.Value(activityInterceptorsContextKey{}).([]ActivityInterceptor)
+		if len(activityInterceptors) == 0 {
+			return call(in)
+		}
+
+		args := make([]interface{}, 0, len(in)-1)
+		for _, v := range in[1:] {
+			args = append(args, v.Interface())
+		}
+
+		// The last handler in the chain calls the real activity function
+		var handler ActivityHandler = func(ctx context.Context, args []interface{}) (interface{}, error) {
+			callArgs := make([]reflect.Value, 0, len(args)+1)
+			callArgs = append(callArgs, reflect.ValueOf(ctx))
+			for i, arg := range args {
+				if arg == nil {
+					callArgs = append(callArgs, reflect.Zero(fnType.In(i+1)))
+				} else {
+					callArgs = append(callArgs, reflect.ValueOf(arg))
+				}
+			}
+			return splitActivityResult(call(callArgs))
+		}
+		for i := len(activityInterceptors) - 1; i >= 0; i-- {
+			interceptor, next := activityInterceptors[i], handler
+			handler = func(ctx context.Context, args []interface{}) (interface{}, error) {
+				return interceptor.InterceptActivity(ctx, activityType, args, next)
+			}
+		}
+
+		result, err := handler(ctx, args)
+		return joinActivityResult(fnType, result, err)
+	}).Interface()
+}
+
+// splitActivityResult converts the return values of an activity function to (result, error)
+func splitActivityResult(out []reflect.Value) (interface{}, error) {
+	var result interface{}
+	var err error
+	if len(out) == 2 {
+		result = out[0].Interface()
+	}
+	if e := out[len(out)-1].Interface(); e != nil {
+		err = e.(error)
+	}
+	return result, err
+}
+
+// joinActivityResult converts (result, error) back to the return values of an activity function
+func joinActivityResult(fnType reflect.Type, result interface{}, err error) []reflect.Value {
+	out := make([]reflect.Value, 0, 2)
+	if fnType.NumOut() == 2 {
+		resultType := fnType.Out(0)
+		if result == nil {
+			out = append(out, reflect.Zero(resultType))
+		} else if rv := reflect.ValueOf(result); rv.Type().AssignableTo(resultType) {
+			out = append(out, rv)
+		} else if rv.Type().ConvertibleTo(resultType) {
+			out = append(out, rv.Convert(resultType))
+		} else {
+			out = append(out, reflect.Zero(resultType))
+			if err == nil {
+				err = errors.New("activity interceptor returned result of type %s, expected %s", rv.Type(), resultType)
+			}
+		}
+	}
+	if err == nil {
+		out = append(out, reflect.Zero(errorType))
+	} else {
+		out = append(out, reflect.ValueOf(&err).Elem())
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package cadence
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/interceptors"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// NewLoggingActivityInterceptor logs the start and the end (with time taken and error) of every activity execution
+func NewLoggingActivityInterceptor(logger *slog.Logger) ActivityInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error) {
+		info := activity.GetInfo(ctx)
+		attrs := []any{
+			slog.String("activityType", activityType),
+			slog.String("activityId", info.ActivityID),
+			slog.String("workflowId", info.WorkflowExecution.ID),
+			slog.String("runId", info.WorkflowExecution.RunID),
+			slog.String("taskList", info.TaskList),
+			slog.Int("attempt", int(info.Attempt)),
+		}
+		logger.Info("activity started", attrs...)
+
+		start := time.Now()
+		result, err := next(ctx, args)
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+		if err != nil {
+			logger.Error("activity failed", append(attrs, slog.String("error", err.Error()))...)
+		} else {
+			logger.Info("activity completed", attrs...)
+		}
+		return result, err
+	})
+}
+
+// PanicError is returned by the panic capture interceptor when an activity panics
+type PanicError struct {
+	ActivityType string
+	Value        interface{}
+	StackTrace   string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("activity %s panicked: %v", e.ActivityType, e.Value)
+}
+
+// NewPanicCaptureActivityInterceptor recovers a panic in an activity, logs it with the stack trace and returns it
+// as a *PanicError to cadence
+func NewPanicCaptureActivityInterceptor(logger *slog.Logger) ActivityInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				pe := &PanicError{ActivityType: activityType, Value: r, StackTrace: string(debug.Stack())}
+				logger.Error("activity panicked", slog.String("activityType", activityType), slog.Any("panic", r), slog.String("stack", pe.StackTrace))
+				result, err = nil, pe
+			}
+		}()
+		return next(ctx, args)
+	})
+}
+
+// NewMetricsActivityInterceptor emits per-activity metrics (started, completed, failed and latency) in the metric
+// scope of the worker. All metrics are tagged with the activity type.
+func NewMetricsActivityInterceptor() ActivityInterceptor {
+	return ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error) {
+		scope := activity.GetMetricsScope(ctx).Tagged(map[string]string{"activity_type": activityType})
+		scope.Counter("gox_activity_started").Inc(1)
+
+		start := time.Now()
+		result, err := next(ctx, args)
+		scope.Timer("gox_activity_latency").Record(time.Since(start))
+		if err != nil {
+			scope.Counter("gox_activity_failed").Inc(1)
+		} else {
+			scope.Counter("gox_activity_completed").Inc(1)
+		}
+		return result, err
+	})
+}
+
+// ActivityAuditRecord is given to the audit function after every activity execution
+type ActivityAuditRecord struct {
+	Info     activity.Info
+	Input    []interface{}
+	Output   interface{}
+	Error    error
+	Duration time.Duration
+}
+
+// NewAuditActivityInterceptor calls the audit function with the input and output of every activity execution
+func NewAuditActivityInterceptor(audit func(ctx context.Context, record ActivityAuditRecord)) ActivityInterceptor {
+	return ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error) {
+		start := time.Now()
+		result, err := next(ctx, args)
+		audit(ctx, ActivityAuditRecord{
+			Info:     activity.GetInfo(ctx),
+			Input:    args,
+			Output:   result,
+			Error:    err,
+			Duration: time.Since(start),
+		})
+		return result, err
+	})
+}
+
+// ErrorClassifier converts an error returned by an activity to the error sent to cadence.
+// e.g. convert a validation error to a cadence.NewCustomError("non-retryable", ...) error
+type ErrorClassifier func(ctx context.Context, activityType string, err error) error
+
+// NewErrorClassifierActivityInterceptor runs the classifier on every error returned by an activity
+func NewErrorClassifierActivityInterceptor(classifier ErrorClassifier) ActivityInterceptor {
+	return ActivityInterceptorFunc(func(ctx context.Context, activityType string, args []interface{}, next ActivityHandler) (interface{}, error) {
+		result, err := next(ctx, args)
+		if err != nil {
+			err = classifier(ctx, activityType, err)
+		}
+		return result, err
+	})
+}
+
+// NewLoggingWorkflowInterceptorFactory logs the start and the end of every workflow execution. Nothing is logged
+// while the workflow is replaying.
+func NewLoggingWorkflowInterceptorFactory() interceptors.WorkflowInterceptorFactory {
+	return &loggingWorkflowInterceptorFactory{}
+}
+
+type loggingWorkflowInterceptorFactory struct {
+}
+
+func (f *loggingWorkflowInterceptorFactory) NewInterceptor(info *workflow.Info, next interceptors.WorkflowInterceptor) interceptors.WorkflowInterceptor {
+	return &loggingWorkflowInterceptor{WorkflowInterceptorBase: interceptors.WorkflowInterceptorBase{Next: next}}
+}
+
+type loggingWorkflowInterceptor struct {
+	interceptors.WorkflowInterceptorBase
+}
+
+func (i *loggingWorkflowInterceptor) ExecuteWorkflow(ctx workflow.Context, workflowType string, args ...interface{}) []interface{} {
+	logger := i.Next.GetLogger(ctx).With(zap.String("workflowType", workflowType))
+	replaying := i.Next.IsReplaying(ctx)
+	if !replaying {
+		logger.Info("workflow started")
+	}
+
+	result := i.Next.ExecuteWorkflow(ctx, workflowType, args...)
+
+	if !i.Next.IsReplaying(ctx) {
+		if err := workflowResultError(result); err != nil {
+			logger.Error("workflow failed", zap.Error(err))
+		} else {
+			logger.Info("workflow completed")
+		}
+	}
+	return result
+}
+
+// NewPanicCaptureWorkflowInterceptorFactory logs the stack trace of a panic in the workflow code and re-panics so
+// cadence handles it as per the NonDeterministicWorkflowPolicy/decision task retry rules.
+func NewPanicCaptureWorkflowInterceptorFactory() interceptors.WorkflowInterceptorFactory {
+	return &panicCaptureWorkflowInterceptorFactory{}
+}
+
+type panicCaptureWorkflowInterceptorFactory struct {
+}
+
+func (f *panicCaptureWorkflowInterceptorFactory) NewInterceptor(info *workflow.Info, next interceptors.WorkflowInterceptor) interceptors.WorkflowInterceptor {
+	return &panicCaptureWorkflowInterceptor{WorkflowInterceptorBase: interceptors.WorkflowInterceptorBase{Next: next}}
+}
+
+type panicCaptureWorkflowInterceptor struct {
+	interceptors.WorkflowInterceptorBase
+}
+
+func (i *panicCaptureWorkflowInterceptor) ExecuteWorkflow(ctx workflow.Context, workflowType string, args ...interface{}) []interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			i.Next.GetLogger(ctx).Error("workflow panicked",
+				zap.String("workflowType", workflowType),
+				zap.Any("panic", r),
+				zap.String("stack", string(debug.Stack())),
+			)
+			panic(r)
+		}
+	}()
+	return i.Next.ExecuteWorkflow(ctx, workflowType, args...)
+}
+
+// workflowResultError returns the error (last value) from the result of a workflow function
+func workflowResultError(result []interface{}) error {
+	if len(result) == 0 {
+		return nil
+	}
+	err, _ := result[len(result)-1].(error)
+	return err
+}
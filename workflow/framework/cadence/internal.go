@@ -1,54 +1,412 @@
 package cadence
 
 import (
+	"fmt"
 	"github.com/devlibx/gox-base/v2/errors"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	// SeverityError is a problem which makes the config invalid
+	SeverityError = "error"
+
+	// SeverityWarning is a suspicious value which does not make the config invalid
+	SeverityWarning = "warning"
+)
+
+// domainNamePattern is the charset allowed by cadence in domain names
+var domainNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ValidationIssue is a problem found by ValidateAll
+type ValidationIssue struct {
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// AppliedDefault is a default value set by ValidateAll
+type AppliedDefault struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// ValidationReport is the result of ValidateAll
+type ValidationReport struct {
+	Issues   []ValidationIssue `json:"issues"`
+	Defaults []AppliedDefault  `json:"defaults"`
+}
+
+func (r *ValidationReport) errorf(path string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) warnf(path string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) applied(path string, value interface{}) {
+	r.Defaults = append(r.Defaults, AppliedDefault{Path: path, Value: fmt.Sprint(value)})
+}
+
+// HasErrors returns true if the config is not valid
+func (r *ValidationReport) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// Errors returns the issues with error severity
+func (r *ValidationReport) Errors() []ValidationIssue {
+	var result []ValidationIssue
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Err returns an error with all the errors of the report (nil if there is no error)
+func (r *ValidationReport) Err() error {
+	issues := r.Errors()
+	if len(issues) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(issues))
+	for _, i := range issues {
+		lines = append(lines, i.Path+": "+i.Message)
+	}
+	return errors.New("config is not valid - %s", strings.Join(lines, "; "))
+}
+
+func (r *ValidationReport) String() string {
+	var sb strings.Builder
+	for _, i := range r.Issues {
+		sb.WriteString(fmt.Sprintf("%s: %s: %s\n", i.Severity, i.Path, i.Message))
+	}
+	return sb.String()
+}
+
+// Validate validates the config (and applies the defaults) - see ValidateAll
 func (c *Config) Validate() error {
+	return c.ValidateAll().Err()
+}
+
+// ValidateAll checks every field of the config and returns all the problems found. It applies the defaults:
+//   - worker group name is the key in worker_groups (a different name in the YAML is reported as a warning)
+//   - shadow: mode "alongside", sampling_rate 1, interval 1m, page_size 100 (if shadow is enabled)
+//   - schedule: overlap_policy "skip", execution_timeout 1h
+//   - logging sampling: tick 1s, initial 100 and thereafter 100 (if both are 0)
+//   - host_resolve_interval 30s
+//   - failover_check_interval 30s (if clusters are set)
+//   - client retry: max_attempts 3, initial_interval 100ms, max_interval 5s, backoff_coefficient 2, jitter 0.2, attempt_timeout 10s
+//   - client circuit_breaker: timeout 1m, max_concurrent_requests 1000, request_volume_threshold 20, sleep_window 5s,
+//     error_percent_threshold 50 (if enabled)
+//
+// Disabled worker groups and workers are not checked and do not count for duplicate task lists.
+func (c *Config) ValidateAll() *ValidationReport {
+	r := &ValidationReport{Issues: make([]ValidationIssue, 0), Defaults: make([]AppliedDefault, 0)}
 
 	// Make sure we have worker groups
-	if c.WorkerGroups == nil || len(c.WorkerGroups) == 0 {
-		return errors.New("worker group is nil or empty")
+	if len(c.WorkerGroups) == 0 {
+		r.errorf("worker_groups", "worker group is nil or empty")
 	}
 
-	// Make sure we do not duplicate task list names across all worker groups
-	taskLists := map[string]string{}
-	for _, wg := range c.WorkerGroups {
-		for _, w := range wg.Workers {
-			if _, ok := taskLists[w.TaskList]; ok {
-				return errors.New("task list is duplicated = %s", w.TaskList)
+	taskLists := map[string]bool{}
+	c.Logging.validate(r, "logging")
+	c.Client.validate(r, "client")
+
+	// Make sure we do not duplicate task list and schedule names across all enabled worker groups
+	taskListPaths := map[string]string{}
+	schedulePaths := map[string]string{}
+	names := make([]string, 0, len(c.WorkerGroups))
+	for name := range c.WorkerGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		wg := c.WorkerGroups[name]
+		path := "worker_groups." + name
+		if len(wg.Name) > 0 && wg.Name != name {
+			r.warnf(path+".name", "name %s is ignored - the worker group name is the key %s", wg.Name, name)
+		}
+		if wg.Name != name {
+			wg.Name = name
+			r.applied(path+".name", name)
+		}
+		if wg.Disabled {
+			c.WorkerGroups[name] = wg
+			continue
+		}
+
+		wg.validate(r, path)
+		c.WorkerGroups[name] = wg
+		for i, w := range wg.Workers {
+			if w == nil || w.Disabled || len(w.TaskList) == 0 {
+				continue
+			}
+			taskLists[w.TaskList] = true
+			workerPath := fmt.Sprintf("%s.worker[%d]", path, i)
+			if other, ok := taskListPaths[w.TaskList]; ok {
+				r.errorf(workerPath+".task_list", "task list %s is duplicated - also used by %s", w.TaskList, other)
+			} else {
+				taskListPaths[w.TaskList] = workerPath
+			}
+		}
+		for i, s := range wg.Schedules {
+			if s == nil || len(s.Name) == 0 {
+				continue
+			}
+			schedulePath := fmt.Sprintf("%s.schedules[%d]", path, i)
+			if other, ok := schedulePaths[s.Name]; ok {
+				r.errorf(schedulePath+".name", "schedule name %s is duplicated - also used by %s", s.Name, other)
+			} else {
+				schedulePaths[s.Name] = schedulePath
 			}
-			taskLists[w.TaskList] = w.TaskList
 		}
 	}
 
-	return nil
+	for taskList := range c.Logging.TaskListLevels {
+		if !taskLists[taskList] {
+			r.warnf("logging.task_list_levels."+taskList, "task list %s is not used by any enabled worker", taskList)
+		}
+	}
+	return r
 }
 
 func (wg *WorkerGroup) Validate() error {
-	if len(wg.Domain) == 0 {
-		return errors.New("domain is empty for worker group = %s", wg.Name)
+	r := &ValidationReport{}
+	wg.validate(r, "worker_groups."+wg.Name)
+	return r.Err()
+}
+
+func (wg *WorkerGroup) validate(r *ValidationReport, path string) {
+	switch {
+	case len(wg.Domain) == 0:
+		r.errorf(path+".domain", "domain is empty")
+	case !domainNamePattern.MatchString(wg.Domain):
+		r.errorf(path+".domain", "domain %s has characters other than letters, digits, '.', '_' and '-'", wg.Domain)
 	}
-	if len(wg.HostPort) == 0 {
-		return errors.New("hostPort is empty for worker group = %s", wg.Name)
+	if len(wg.Clusters) == 0 {
+		validateHostPort(r, path+".host_port", wg.HostPort)
+	} else {
+		wg.validateClusters(r, path)
 	}
-	if wg.Workers == nil || len(wg.Workers) == 0 {
-		return errors.New("workers is empty for worker group = %s", wg.Name)
+	switch {
+	case wg.HostResolveInterval < 0:
+		r.errorf(path+".host_resolve_interval", "host_resolve_interval must not be negative - found %s", wg.HostResolveInterval)
+	case wg.HostResolveInterval == 0:
+		wg.HostResolveInterval = defaultHostResolveInterval
+		r.applied(path+".host_resolve_interval", wg.HostResolveInterval)
 	}
-	for _, wg := range wg.Workers {
-		if err := wg.Validate(); err != nil {
-			return err
+	wg.Shadow.validate(r, path+".shadow")
+
+	enabled := 0
+	groupTaskLists := map[string]bool{}
+	for i, w := range wg.Workers {
+		workerPath := fmt.Sprintf("%s.worker[%d]", path, i)
+		if w == nil {
+			r.errorf(workerPath, "worker is empty")
+			continue
+		}
+		if w.Disabled {
+			continue
+		}
+		enabled++
+		groupTaskLists[w.TaskList] = true
+		w.validate(r, workerPath)
+	}
+	if enabled == 0 {
+		r.errorf(path+".worker", "workers is empty (or all workers are disabled)")
+	}
+
+	for i, s := range wg.Schedules {
+		schedulePath := fmt.Sprintf("%s.schedules[%d]", path, i)
+		if s == nil {
+			r.errorf(schedulePath, "schedule is empty")
+			continue
+		}
+		overlapPolicy, executionTimeout := s.OverlapPolicy, s.ExecutionTimeout
+		if err := s.Validate(groupTaskLists); err != nil {
+			r.errorf(schedulePath, "%s", err.Error())
+			continue
+		}
+		if s.ExecutionTimeout < 0 {
+			r.errorf(schedulePath+".execution_timeout", "execution_timeout must not be negative - found %s", s.ExecutionTimeout)
+		}
+		if overlapPolicy != s.OverlapPolicy {
+			r.applied(schedulePath+".overlap_policy", s.OverlapPolicy)
+		}
+		if executionTimeout != s.ExecutionTimeout {
+			r.applied(schedulePath+".execution_timeout", s.ExecutionTimeout)
+		}
+	}
+}
+
+// validateClusters checks the clusters of a global domain and applies the failover check interval default
+func (wg *WorkerGroup) validateClusters(r *ValidationReport, path string) {
+	if len(wg.HostPort) > 0 {
+		r.warnf(path+".host_port", "host_port %s is ignored - the worker group connects to the active cluster", wg.HostPort)
+	}
+	names := map[string]bool{}
+	for i, c := range wg.Clusters {
+		clusterPath := fmt.Sprintf("%s.clusters[%d]", path, i)
+		if c == nil {
+			r.errorf(clusterPath, "cluster is empty")
+			continue
+		}
+		switch {
+		case len(c.Name) == 0:
+			r.errorf(clusterPath+".name", "cluster name is empty")
+		case names[c.Name]:
+			r.errorf(clusterPath+".name", "cluster name %s is duplicated", c.Name)
+		}
+		names[c.Name] = true
+		validateHostPort(r, clusterPath+".host_port", c.HostPort)
+	}
+	switch {
+	case wg.FailoverCheckInterval < 0:
+		r.errorf(path+".failover_check_interval", "failover_check_interval must not be negative - found %s", wg.FailoverCheckInterval)
+	case wg.FailoverCheckInterval == 0:
+		wg.FailoverCheckInterval = defaultFailoverCheckInterval
+		r.applied(path+".failover_check_interval", wg.FailoverCheckInterval)
+	}
+}
+
+// validateHostPort checks the host:port syntax of every entry of the comma separated list
+func validateHostPort(r *ValidationReport, path string, hostPort string) {
+	hostPorts := splitHostPorts(hostPort)
+	if len(hostPorts) == 0 {
+		r.errorf(path, "host_port is empty")
+		return
+	}
+	for _, hp := range hostPorts {
+		host, port, err := net.SplitHostPort(hp)
+		if err != nil {
+			r.errorf(path, "host_port %s is not host:port - %s", hp, err.Error())
+			continue
+		}
+		if len(host) == 0 {
+			r.errorf(path, "host is empty in host_port %s", hp)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			r.errorf(path, "port must be a number in range [1, 65535] in host_port %s", hp)
 		}
 	}
-	return nil
 }
 
 func (s *Worker) Validate() error {
+	r := &ValidationReport{}
+	s.validate(r, "worker")
+	return r.Err()
+}
+
+func (s *Worker) validate(r *ValidationReport, path string) {
 	if len(s.TaskList) == 0 {
-		return errors.New("TaskList is empty")
+		r.errorf(path+".task_list", "TaskList is empty")
 	}
 	if s.WorkerCount < 0 {
-		return errors.New("WorkerCount is less than 0")
+		r.errorf(path+".worker_count", "WorkerCount is less than 0")
+	}
+
+	rates := map[string]float64{
+		"activities_per_second":           s.ActivitiesPerSecond,
+		"task_list_activities_per_second": s.TaskListActivitiesPerSecond,
+		"decisions_per_second":            s.DecisionsPerSecond,
+	}
+	sizes := map[string]int{
+		"max_concurrent_activities": s.MaxConcurrentActivities,
+		"max_concurrent_decisions":  s.MaxConcurrentDecisions,
+		"activity_pollers":          s.ActivityPollers,
+		"decision_pollers":          s.DecisionPollers,
+	}
+	for _, name := range []string{"activities_per_second", "task_list_activities_per_second", "decisions_per_second"} {
+		if rates[name] < 0 {
+			r.errorf(path+"."+name, "%s must not be negative - found %v", name, rates[name])
+		}
+	}
+	for _, name := range []string{"max_concurrent_activities", "max_concurrent_decisions", "activity_pollers", "decision_pollers"} {
+		if sizes[name] < 0 {
+			r.errorf(path+"."+name, "%s must not be negative - found %d", name, sizes[name])
+		}
+	}
+
+	if s.TaskListActivitiesPerSecond > 0 && s.ActivitiesPerSecond > s.TaskListActivitiesPerSecond {
+		r.warnf(path+".activities_per_second", "activities_per_second %v is more than task_list_activities_per_second %v - the task list limit is used", s.ActivitiesPerSecond, s.TaskListActivitiesPerSecond)
+	}
+	if s.MaxConcurrentActivities > 0 && s.ActivityPollers > s.MaxConcurrentActivities {
+		r.warnf(path+".activity_pollers", "activity_pollers %d is more than max_concurrent_activities %d", s.ActivityPollers, s.MaxConcurrentActivities)
+	}
+	if s.MaxConcurrentDecisions > 0 && s.DecisionPollers > s.MaxConcurrentDecisions {
+		r.warnf(path+".decision_pollers", "decision_pollers %d is more than max_concurrent_decisions %d", s.DecisionPollers, s.MaxConcurrentDecisions)
+	}
+	s.Shadow.validate(r, path+".shadow")
+}
+
+// validate checks the shadow config and applies the defaults
+func (s *ShadowConfig) validate(r *ValidationReport, path string) {
+	if err := s.Validate(); err != nil {
+		r.errorf(path, "%s", err.Error())
+		return
+	}
+	if s == nil || !s.Enabled {
+		return
+	}
+	if len(s.Mode) == 0 {
+		s.Mode = ShadowModeAlongside
+		r.applied(path+".mode", s.Mode)
+	}
+	if s.SamplingRate == 0 {
+		s.SamplingRate = 1
+		r.applied(path+".sampling_rate", s.SamplingRate)
+	}
+	if s.Interval == 0 {
+		s.Interval = defaultShadowInterval
+		r.applied(path+".interval", s.Interval)
+	}
+	if s.PageSize == 0 {
+		s.PageSize = defaultShadowPageSize
+		r.applied(path+".page_size", s.PageSize)
+	}
+}
+
+// validate checks every logging field
+func (c *LoggingConfig) validate(r *ValidationReport, path string) {
+	switch c.Sink {
+	case "", LogSinkSlog, LogSinkGox, LogSinkZap:
+	default:
+		r.errorf(path+".sink", "logging sink must be one of slog, gox or zap - found %s", c.Sink)
+	}
+	switch c.Encoding {
+	case "", "json", "console":
+	default:
+		r.errorf(path+".encoding", "logging encoding must be json or console - found %s", c.Encoding)
+	}
+	if _, err := parseLogLevel(c.Level); err != nil {
+		r.errorf(path+".level", "%s", err.Error())
+	}
+	for taskList, level := range c.TaskListLevels {
+		if _, err := parseLogLevel(level); err != nil {
+			r.errorf(path+".task_list_levels."+taskList, "%s", err.Error())
+		}
+	}
+	if c.Sampling != nil {
+		s := c.Sampling
+		if s.Tick < 0 || s.Initial < 0 || s.Thereafter < 0 {
+			r.errorf(path+".sampling", "tick, initial and thereafter must not be negative")
+		}
+		if s.Tick == 0 {
+			s.Tick = time.Second
+			r.applied(path+".sampling.tick", s.Tick)
+		}
+		// Both zero would drop every log
+		if s.Initial == 0 && s.Thereafter == 0 {
+			s.Initial, s.Thereafter = 100, 100
+			r.applied(path+".sampling.initial", s.Initial)
+			r.applied(path+".sampling.thereafter", s.Thereafter)
+		}
 	}
-	return nil
 }
@@ -0,0 +1,380 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"time"
+)
+
+const (
+	// LogSinkSlog sends all logs to the slog handler (default slog handler or the one set with WithSlogHandler)
+	LogSinkSlog = "slog"
+
+	// LogSinkGox sends all logs to the zap logger of gox.CrossFunction
+	LogSinkGox = "gox"
+
+	// LogSinkZap sends all logs to a zap logger built from this config (level, encoding)
+	LogSinkZap = "zap"
+)
+
+// LoggingConfig is the configuration for the logs generated by this wrapper and by cadence workers/clients.
+//
+// All logs (wrapper logs and cadence logs) are sent to one sink so that they end up in one pipeline.
+type LoggingConfig struct {
+	// Sink is one of "slog" (default), "gox" or "zap"
+	Sink string `json:"sink" yaml:"sink"`
+
+	// Level is the minimum log level - debug, info (default), warn or error
+	Level string `json:"level" yaml:"level"`
+
+	// Encoding is used with "zap" sink - json (default) or console
+	Encoding string `json:"encoding" yaml:"encoding"`
+
+	// Sampling if set will sample the cadence logs
+	Sampling *LogSamplingConfig `json:"sampling" yaml:"sampling"`
+
+	// TaskListLevels allows to change the log level for the cadence worker of a task list
+	TaskListLevels map[string]string `json:"task_list_levels" yaml:"task_list_levels"`
+}
+
+// LogSamplingConfig logs first "Initial" entries with same level and message in each "Tick", and then every
+// "Thereafter"th entry. Tick defaults to 1s; Initial and Thereafter default to 100 if both are 0.
+type LogSamplingConfig struct {
+	Tick       time.Duration `json:"tick" yaml:"tick"`
+	Initial    int           `json:"initial" yaml:"initial"`
+	Thereafter int           `json:"thereafter" yaml:"thereafter"`
+}
+
+// WithSlogHandler sets the slog handler used by the "slog" log sink. If not set then slog.Default() handler is used
+func WithSlogHandler(handler slog.Handler) Option {
+	return func(wrapper *cadenceWrapperImpl) {
+		wrapper.slogHandler = handler
+	}
+}
+
+func (c *LoggingConfig) Validate() error {
+	r := &ValidationReport{}
+	c.validate(r, "logging")
+	return r.Err()
+}
+
+func parseLogLevel(level string) (zapcore.Level, error) {
+	if len(level) == 0 {
+		return zapcore.InfoLevel, nil
+	}
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return l, errors.Wrap(err, "bad log level = %s", level)
+	}
+	return l, nil
+}
+
+// logging builds the loggers for the wrapper (slog) and the cadence workers (zap) which write to the same sink
+type logging struct {
+	config    LoggingConfig
+	baseCore  zapcore.Core
+	zapLogger *zap.Logger
+	slogger   *slog.Logger
+}
+
+func newLogging(config LoggingConfig, cf gox.CrossFunction, handler slog.Handler, enableErrorStack bool) (*logging, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+
+	l := &logging{config: config}
+	level, _ := parseLogLevel(config.Level)
+
+	switch config.Sink {
+	case "", LogSinkSlog:
+		l.baseCore = newSlogCore(handler)
+		l.slogger = slog.New(&levelSlogHandler{Handler: handler, level: toSlogLevel(level)})
+
+	case LogSinkGox:
+		var goxLogger *zap.Logger
+		if cf != nil {
+			goxLogger = cf.Logger()
+		}
+		if goxLogger == nil {
+			goxLogger = zap.NewNop()
+		}
+		l.baseCore = goxLogger.Core()
+		l.slogger = slog.New(newZapSlogHandler(newLevelFilterCore(l.baseCore, level)))
+
+	case LogSinkZap:
+		zc := zap.NewProductionConfig()
+		if config.Encoding == "console" {
+			zc = zap.NewDevelopmentConfig()
+		}
+		zc.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		zc.Sampling = nil
+		zapLogger, err := zc.Build()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create zap logger")
+		}
+		l.baseCore = zapLogger.Core()
+		l.slogger = slog.New(newZapSlogHandler(newLevelFilterCore(l.baseCore, level)))
+	}
+
+	// The errors in the cadence logs are not very helpful. So we remove stack trace unless it is enabled
+	if !enableErrorStack {
+		l.baseCore = &stackStripCore{Core: l.baseCore}
+	}
+	l.zapLogger = l.newZapLogger(level)
+	return l, nil
+}
+
+// zapLoggerForTaskList returns the zap logger to be used by cadence worker for the given task list
+func (l *logging) zapLoggerForTaskList(taskList string) *zap.Logger {
+	if levelStr, ok := l.config.TaskListLevels[taskList]; ok {
+		level, _ := parseLogLevel(levelStr)
+		return l.newZapLogger(level).Named("cadence-worker-" + taskList)
+	}
+	return l.zapLogger.Named("cadence-worker-" + taskList)
+}
+
+func (l *logging) newZapLogger(level zapcore.Level) *zap.Logger {
+	core := newLevelFilterCore(l.baseCore, level)
+	if s := l.config.Sampling; s != nil {
+		tick := s.Tick
+		if tick <= 0 {
+			tick = time.Second
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, s.Initial, s.Thereafter)
+	}
+	return zap.New(core)
+}
+
+// levelFilterCore is a zap core which only allows the logs with level >= given level
+type levelFilterCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func newLevelFilterCore(core zapcore.Core, level zapcore.LevelEnabler) zapcore.Core {
+	return &levelFilterCore{Core: core, level: level}
+}
+
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelFilterCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// stackStripCore removes the stack trace and verbose error (with stack) from the log entry
+type stackStripCore struct {
+	zapcore.Core
+}
+
+func (c *stackStripCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackStripCore{Core: c.Core.With(stripErrorStack(fields))}
+}
+
+func (c *stackStripCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *stackStripCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Stack = ""
+	return c.Core.Write(entry, stripErrorStack(fields))
+}
+
+// stripErrorStack converts error fields to string fields - zap adds "errorVerbose" (with stack) for errors which
+// implement fmt.Formatter
+func stripErrorStack(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		if out == nil {
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		if err, ok := f.Interface.(error); ok && err != nil {
+			out[i] = zap.String(f.Key, err.Error())
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// slogCore is a zap core which writes all logs to a slog handler
+type slogCore struct {
+	handler slog.Handler
+	attrs   []slog.Attr
+}
+
+func newSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{handler: handler}
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), toSlogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	attrs := make([]slog.Attr, 0, len(c.attrs)+len(fields))
+	attrs = append(attrs, c.attrs...)
+	attrs = append(attrs, toSlogAttrs(fields)...)
+	return &slogCore{handler: c.handler, attrs: attrs}
+}
+
+func (c *slogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, toSlogLevel(entry.Level), entry.Message, 0)
+	if len(entry.LoggerName) > 0 {
+		record.AddAttrs(slog.String("logger", entry.LoggerName))
+	}
+	record.AddAttrs(c.attrs...)
+	record.AddAttrs(toSlogAttrs(fields)...)
+	if len(entry.Stack) > 0 {
+		record.AddAttrs(slog.String("stacktrace", entry.Stack))
+	}
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+func toSlogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func toSlogAttrs(fields []zapcore.Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for k, v := range enc.Fields {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	return attrs
+}
+
+// levelSlogHandler is a slog handler which only allows the logs with level >= given level
+type levelSlogHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h *levelSlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelSlogHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelSlogHandler) WithGroup(name string) slog.Handler {
+	return &levelSlogHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// zapSlogHandler is a slog handler which writes all logs to a zap core
+type zapSlogHandler struct {
+	core   zapcore.Core
+	prefix string
+}
+
+func newZapSlogHandler(core zapcore.Core) slog.Handler {
+	return &zapSlogHandler{core: core}
+}
+
+func (h *zapSlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(toZapLevel(level))
+}
+
+func (h *zapSlogHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := zapcore.Entry{Level: toZapLevel(record.Level), Time: record.Time, Message: record.Message}
+	ce := h.core.Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+	fields := make([]zapcore.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendZapFields(fields, h.prefix, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *zapSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendZapFields(fields, h.prefix, attr)
+	}
+	return &zapSlogHandler{core: h.core.With(fields), prefix: h.prefix}
+}
+
+func (h *zapSlogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &zapSlogHandler{core: h.core, prefix: h.prefix + name + "."}
+}
+
+func toZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func appendZapFields(fields []zapcore.Field, prefix string, attr slog.Attr) []zapcore.Field {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if len(attr.Key) > 0 {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, a := range value.Group() {
+			fields = appendZapFields(fields, groupPrefix, a)
+		}
+		return fields
+	}
+	return append(fields, zap.Any(prefix+attr.Key, value.Any()))
+}
@@ -3,6 +3,7 @@ package cadence
 import (
 	"context"
 	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/client"
 	"go.uber.org/cadence/encoded"
 	"go.uber.org/cadence/workflow"
@@ -38,6 +39,78 @@ func (n noOpCadenceApi) QueryWorkflow(ctx context.Context, workflowID string, ru
 	return nil, errors.New("cannot start query - no op cadence api implementation")
 }
 
+func (n noOpCadenceApi) GetWorkflow(ctx context.Context, workflowID string, runID string) (client.WorkflowRun, error) {
+	return nil, errors.New("cannot get workflow - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	return errors.New("cannot send signal - no op cadence api implementation")
+}
+
 func (n noOpCadenceApi) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error {
 	return nil
 }
+
+func (n noOpCadenceApi) GetWorkflowHistory(ctx context.Context, workflowID string, runID string) (*shared.History, error) {
+	return nil, errors.New("cannot get workflow history - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ListWorkflow(ctx context.Context, request *shared.ListWorkflowExecutionsRequest) (*shared.ListWorkflowExecutionsResponse, error) {
+	return nil, errors.New("cannot list workflow - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) DescribeWorkflowExecution(ctx context.Context, workflowID string, runID string) (*shared.DescribeWorkflowExecutionResponse, error) {
+	return nil, errors.New("cannot describe workflow - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ResetWorkflow(ctx context.Context, request *shared.ResetWorkflowExecutionRequest) (*shared.ResetWorkflowExecutionResponse, error) {
+	return nil, errors.New("cannot reset workflow - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ListSchedules(ctx context.Context) ([]ScheduleInfo, error) {
+	return nil, errors.New("cannot list schedules - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) PauseSchedule(ctx context.Context, name string) error {
+	return errors.New("cannot pause schedule - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ResumeSchedule(ctx context.Context, name string) error {
+	return errors.New("cannot resume schedule - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) MarkBadBinary(ctx context.Context, domain string, checksum string, reason string) error {
+	return errors.New("cannot mark bad binary - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) RemoveBadBinary(ctx context.Context, domain string, checksum string) error {
+	return errors.New("cannot remove bad binary - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ListBadBinaries(ctx context.Context, domain string) ([]BadBinary, error) {
+	return nil, errors.New("cannot list bad binaries - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) AddWorker(ctx context.Context, workerGroup string, worker Worker) error {
+	return errors.New("cannot add worker - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) RemoveWorker(ctx context.Context, taskList string) error {
+	return errors.New("cannot remove worker - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) PauseWorker(ctx context.Context, taskList string) error {
+	return errors.New("cannot pause worker - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ResumeWorker(ctx context.Context, taskList string) error {
+	return errors.New("cannot resume worker - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) ReloadConfig(ctx context.Context, config *Config) (*ConfigReloadReport, error) {
+	return nil, errors.New("cannot reload config - no op cadence api implementation")
+}
+
+func (n noOpCadenceApi) Health(ctx context.Context) ([]WorkerGroupHealth, error) {
+	return make([]WorkerGroupHealth, 0), nil
+}
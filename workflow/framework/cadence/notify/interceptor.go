@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	gocadence "go.uber.org/cadence"
+	"go.uber.org/cadence/interceptors"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+// WorkflowInterceptorFactory returns the interceptor which runs the notification activity when a workflow with a
+// callback finishes. Add it to cadence.WorkerInterceptors.Workflow.
+func (n *Notifier) WorkflowInterceptorFactory() interceptors.WorkflowInterceptorFactory {
+	return &interceptorFactory{notifier: n}
+}
+
+type interceptorFactory struct {
+	notifier *Notifier
+}
+
+func (f *interceptorFactory) NewInterceptor(info *workflow.Info, next interceptors.WorkflowInterceptor) interceptors.WorkflowInterceptor {
+	// The memo and the type callbacks do not change between replays, so the decision to notify is deterministic
+	callback, ok := f.notifier.callbackFor(info)
+	if !ok {
+		return next
+	}
+	return &interceptor{WorkflowInterceptorBase: interceptors.WorkflowInterceptorBase{Next: next}, notifier: f.notifier, callback: callback, info: info}
+}
+
+// callbackFor returns the callback from the memo, or the one set for the workflow type
+func (n *Notifier) callbackFor(info *workflow.Info) (Callback, bool) {
+	if info.Memo != nil {
+		if data, ok := info.Memo.Fields[MemoKey]; ok {
+			callback := Callback{}
+			if err := json.Unmarshal(data, &callback); err == nil {
+				return callback, true
+			}
+		}
+	}
+	callback, ok := n.typeCallbacks[info.WorkflowType.Name]
+	return callback, ok
+}
+
+type interceptor struct {
+	interceptors.WorkflowInterceptorBase
+	notifier *Notifier
+	callback Callback
+	info     *workflow.Info
+}
+
+func (i *interceptor) ExecuteWorkflow(ctx workflow.Context, workflowType string, args ...interface{}) []interface{} {
+	result := i.Next.ExecuteWorkflow(ctx, workflowType, args...)
+
+	var err error
+	if len(result) > 0 {
+		err, _ = result[len(result)-1].(error)
+	}
+
+	// The next run will notify when it finishes
+	var continueAsNew *workflow.ContinueAsNewError
+	if err != nil && errors.As(err, &continueAsNew) {
+		return result
+	}
+
+	info := i.info
+	notification := Notification{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		WorkflowType: workflowType,
+		TaskList:     info.TaskListName,
+		Domain:       info.Domain,
+		Status:       StatusCompleted,
+		Time:         i.Next.Now(ctx),
+	}
+	switch {
+	case err != nil && gocadence.IsCanceledError(err):
+		notification.Status = StatusCancelled
+		notification.Error = err.Error()
+	case err != nil:
+		notification.Status = StatusFailed
+		notification.Error = err.Error()
+	case len(result) == 2 && result[0] != nil:
+		if data, e := json.Marshal(result[0]); e == nil {
+			notification.Result = data
+		}
+	}
+
+	// A disconnected context is used so the notification is sent for cancelled workflows too
+	activityCtx, _ := workflow.NewDisconnectedContext(ctx)
+	activityCtx = workflow.WithActivityOptions(activityCtx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    i.notifier.activityTimeout(),
+		HeartbeatTimeout:       i.notifier.timeout + i.notifier.maxBackoff + time.Minute,
+	})
+	if e := i.Next.ExecuteActivity(activityCtx, ActivityName, i.callback, notification).Get(activityCtx, nil); e != nil {
+		i.Next.GetLogger(ctx).Error("failed to run workflow completion notification activity", zap.Error(e))
+	}
+	return result
+}
@@ -0,0 +1,262 @@
+// Package notify sends a notification when a workflow finishes, so the caller of StartWorkflow does not have to poll.
+//
+// The notification is sent by a system activity which a workflow interceptor runs after the workflow function
+// returns. The callback is set per start call (WithCallback puts it in the workflow memo) or per workflow type
+// (WithWorkflowTypeCallback). It is POSTed to the callback URL with an HMAC signature and retries, or published to an
+// event sink. Notifications which could not be delivered go to the dead letter log.
+//
+//	notifier := notify.NewNotifier(notify.WithSigningSecret(os.Getenv("CALLBACK_SECRET")))
+//	notifier.Register()
+//	api, err := cadence.NewCadenceClient(cf, config, cadence.WithWorkerInterceptors(cadence.WorkerInterceptors{
+//		Workflow: []interceptors.WorkflowInterceptorFactory{notifier.WorkflowInterceptorFactory()},
+//	}))
+//
+//	options := client.StartWorkflowOptions{...}
+//	notify.WithCallback(&options, notify.Callback{URL: "https://orders.internal/callbacks/workflow"})
+//	api.StartWorkflow(ctx, options, "main.OrderWorkflow", order)
+//
+// Only completed, failed and cancelled workflows are notified - timed out and terminated workflows never return to the
+// worker.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// ActivityName is the name of the system activity which delivers the notification
+	ActivityName = "gox.notify.DeliverCompletion"
+
+	// MemoKey is the workflow memo key which holds the callback set with WithCallback
+	MemoKey = "gox_completion_callback"
+
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Callback tells where the notification of a workflow is sent
+type Callback struct {
+	// URL to POST the notification to
+	URL string `json:"url,omitempty"`
+
+	// Sink is the name of the sink (see WithSink) to publish the notification to. It is used instead of URL if set.
+	Sink string `json:"sink,omitempty"`
+
+	// Headers are added to the POST request
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Notification is sent when a workflow finishes
+type Notification struct {
+	WorkflowID   string          `json:"workflow_id"`
+	RunID        string          `json:"run_id"`
+	WorkflowType string          `json:"workflow_type"`
+	TaskList     string          `json:"task_list"`
+	Domain       string          `json:"domain"`
+	Status       string          `json:"status"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Time         time.Time       `json:"time"`
+}
+
+// Sink publishes notifications e.g. to a message queue
+type Sink interface {
+	Publish(ctx context.Context, notification Notification) error
+}
+
+// SinkFunc is a function adapter for Sink
+type SinkFunc func(ctx context.Context, notification Notification) error
+
+func (f SinkFunc) Publish(ctx context.Context, notification Notification) error {
+	return f(ctx, notification)
+}
+
+// DeadLetterLogger is called with the notification which could not be delivered after all the attempts
+type DeadLetterLogger func(ctx context.Context, callback Callback, notification Notification, err error)
+
+// WithCallback sets the callback for a single start call (in the workflow memo)
+func WithCallback(options *client.StartWorkflowOptions, callback Callback) {
+	if options.Memo == nil {
+		options.Memo = map[string]interface{}{}
+	}
+	options.Memo[MemoKey] = callback
+}
+
+// Notifier delivers completion notifications
+type Notifier struct {
+	secret        string
+	httpClient    *http.Client
+	maxAttempts   int
+	backoff       time.Duration
+	maxBackoff    time.Duration
+	timeout       time.Duration
+	sinks         map[string]Sink
+	typeCallbacks map[string]Callback
+	deadLetter    DeadLetterLogger
+	logger        *slog.Logger
+}
+
+// Option configures the Notifier
+type Option func(n *Notifier)
+
+// WithSigningSecret sets the HMAC secret used to sign webhook requests (see Sign)
+func WithSigningSecret(secret string) Option {
+	return func(n *Notifier) {
+		n.secret = secret
+	}
+}
+
+// WithHTTPClient sets the http client used for webhooks
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(n *Notifier) {
+		n.httpClient = httpClient
+	}
+}
+
+// WithRetry sets the max delivery attempts (default 5) and the initial backoff (default 1s, doubled on every
+// attempt up to 1m)
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(n *Notifier) {
+		n.maxAttempts = maxAttempts
+		n.backoff = backoff
+	}
+}
+
+// WithTimeout sets the timeout of a single delivery attempt (default 10s)
+func WithTimeout(timeout time.Duration) Option {
+	return func(n *Notifier) {
+		n.timeout = timeout
+	}
+}
+
+// WithSink adds a named sink which can be used in Callback.Sink
+func WithSink(name string, sink Sink) Option {
+	return func(n *Notifier) {
+		n.sinks[name] = sink
+	}
+}
+
+// WithWorkflowTypeCallback sets the callback for all executions of a workflow type. A callback given with
+// WithCallback in the start call takes precedence.
+func WithWorkflowTypeCallback(workflowType string, callback Callback) Option {
+	return func(n *Notifier) {
+		n.typeCallbacks[workflowType] = callback
+	}
+}
+
+// WithDeadLetterLogger sets the function called with undelivered notifications. By default they are logged as errors.
+func WithDeadLetterLogger(deadLetter DeadLetterLogger) Option {
+	return func(n *Notifier) {
+		n.deadLetter = deadLetter
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(n *Notifier) {
+		n.logger = logger
+	}
+}
+
+// NewNotifier creates a notifier
+func NewNotifier(opts ...Option) *Notifier {
+	n := &Notifier{
+		httpClient:    http.DefaultClient,
+		maxAttempts:   5,
+		backoff:       time.Second,
+		maxBackoff:    time.Minute,
+		timeout:       10 * time.Second,
+		sinks:         map[string]Sink{},
+		typeCallbacks: map[string]Callback{},
+		logger:        slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.maxAttempts <= 0 {
+		n.maxAttempts = 1
+	}
+	if n.deadLetter == nil {
+		n.deadLetter = func(ctx context.Context, callback Callback, notification Notification, err error) {
+			n.logger.Error("dead letter: failed to deliver workflow completion notification",
+				slog.String("url", callback.URL),
+				slog.String("sink", callback.Sink),
+				slog.Any("notification", notification),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+	return n
+}
+
+// Register registers the system activity which delivers the notifications. It must be called in every process
+// running workers which use the interceptor.
+func (n *Notifier) Register() {
+	activity.RegisterWithOptions(n.deliverActivity, activity.RegisterOptions{Name: ActivityName})
+}
+
+// activityTimeout is the max time taken by the activity - all attempts with backoff
+func (n *Notifier) activityTimeout() time.Duration {
+	total := time.Duration(0)
+	backoff := n.backoff
+	for i := 0; i < n.maxAttempts; i++ {
+		total += n.timeout + backoff
+		backoff = min(backoff*2, n.maxBackoff)
+	}
+	return total + time.Minute
+}
+
+// deliverActivity delivers the notification with retries. Undelivered notifications are sent to the dead letter log
+// and the activity does not fail, so the workflow is never blocked by a callback.
+func (n *Notifier) deliverActivity(ctx context.Context, callback Callback, notification Notification) error {
+	var err error
+	backoff := n.backoff
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if err = n.deliver(ctx, callback, notification); err == nil {
+			return nil
+		}
+		n.logger.Warn("failed to deliver workflow completion notification",
+			slog.String("workflowId", notification.WorkflowID),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+		if attempt == n.maxAttempts {
+			break
+		}
+
+		activity.RecordHeartbeat(ctx, attempt)
+		select {
+		case <-ctx.Done():
+			n.deadLetter(ctx, callback, notification, err)
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, n.maxBackoff)
+	}
+
+	n.deadLetter(ctx, callback, notification, err)
+	return nil
+}
+
+// deliver makes one delivery attempt
+func (n *Notifier) deliver(ctx context.Context, callback Callback, notification Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	if len(callback.Sink) > 0 {
+		sink, ok := n.sinks[callback.Sink]
+		if !ok {
+			return errors.New("sink is not configured: %s", callback.Sink)
+		}
+		return sink.Publish(ctx, notification)
+	}
+	return n.post(ctx, callback, notification)
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderSignature has the HMAC-SHA256 signature of the request - "sha256=<hex>"
+	HeaderSignature = "X-Gox-Signature"
+
+	// HeaderTimestamp has the unix time (seconds) used in the signature
+	HeaderTimestamp = "X-Gox-Timestamp"
+)
+
+// Sign returns the signature of the body - HMAC-SHA256 of "<timestamp>.<body>" with the secret
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequest checks the signature of a webhook request. It is used by the receiver of the callback. Requests with
+// a timestamp older than maxAge are rejected (maxAge 0 disables the check). The body is read and returned.
+func VerifyRequest(secret string, r *http.Request, maxAge time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	timestamp := r.Header.Get(HeaderTimestamp)
+	if maxAge > 0 {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, errors.New("bad %s header: %s", HeaderTimestamp, timestamp)
+		}
+		if time.Since(time.Unix(ts, 0)) > maxAge {
+			return nil, errors.New("request is too old: %s", timestamp)
+		}
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(r.Header.Get(HeaderSignature))) {
+		return nil, errors.New("bad signature")
+	}
+	return body, nil
+}
+
+// post sends the notification to the callback URL. Any non 2xx response is an error.
+func (n *Notifier) post(ctx context.Context, callback Callback, notification Notification) error {
+	if len(callback.URL) == 0 {
+		return errors.New("callback has neither url nor sink")
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create callback request: %s", callback.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range callback.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(n.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(n.secret, timestamp, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call callback url: %s", callback.URL)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("callback url %s returned status %d", callback.URL, resp.StatusCode)
+	}
+	return nil
+}
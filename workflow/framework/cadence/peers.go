@@ -0,0 +1,213 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	yarpcpeer "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/transport/tchannel"
+	"log/slog"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHostResolveInterval = 30 * time.Second
+	hostResolveTimeout         = 5 * time.Second
+)
+
+// startDispatcher creates a dispatcher with the cadence frontend outbound and starts it. hostPort is a comma
+// separated list of host:port - the requests are load balanced (round-robin) across the frontends. Host names are
+// resolved to all their addresses and re-resolved every resolveInterval. A frontend which can not be connected is
+// not used until the transport connects to it again.
+func startDispatcher(serviceName string, hostPort string, resolveInterval time.Duration, slogger *slog.Logger) (*yarpc.Dispatcher, error) {
+	peerTransport, err := tchannel.NewTransport(tchannel.ServiceName(serviceName))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create transport for cadenceClient=%s", serviceName)
+	}
+
+	updater := &peerUpdater{
+		hostPorts: splitHostPorts(hostPort),
+		interval:  resolveInterval,
+		resolve:   net.DefaultResolver.LookupHost,
+		slogger:   slogger.With(slog.String("hostPort", hostPort)),
+	}
+	chooser := yarpcpeer.Bind(roundrobin.New(peerTransport), updater.bind)
+
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: serviceName,
+		Outbounds: yarpc.Outbounds{
+			cadenceService: {Unary: peerTransport.NewOutbound(chooser)},
+		},
+	})
+
+	if err = dispatcher.Start(); err != nil {
+		return nil, errors.Wrap(err, "field to start dispatcher for cadenceClient=%s", serviceName)
+	}
+
+	return dispatcher, nil
+}
+
+// splitHostPorts returns the entries of a comma separated host:port list
+func splitHostPorts(hostPort string) []string {
+	var result []string
+	for _, hp := range strings.Split(hostPort, ",") {
+		if hp = strings.TrimSpace(hp); len(hp) > 0 {
+			result = append(result, hp)
+		}
+	}
+	return result
+}
+
+// peerUpdater keeps the peer list updated with the resolved addresses of the host:port list
+type peerUpdater struct {
+	hostPorts []string
+	interval  time.Duration
+	resolve   func(ctx context.Context, host string) ([]string, error)
+	slogger   *slog.Logger
+
+	list    peer.List
+	current []string
+
+	// resolved are the last resolved addresses of each host:port - used when the host can not be resolved
+	resolved map[string][]string
+	cancel   context.CancelFunc
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+// bind is the peer.Binder of the updater
+func (u *peerUpdater) bind(list peer.List) transport.Lifecycle {
+	u.list = list
+	return u
+}
+
+// Start adds the resolved addresses to the peer list - it fails if no address is resolved
+func (u *peerUpdater) Start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), hostResolveTimeout)
+	defer cancel()
+	if err := u.update(ctx); err != nil {
+		return err
+	}
+
+	interval := u.interval
+	if interval <= 0 {
+		interval = defaultHostResolveInterval
+	}
+	ctx, u.cancel = context.WithCancel(context.Background())
+	u.done = make(chan struct{})
+	go func() {
+		defer close(u.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				resolveCtx, cancel := context.WithTimeout(ctx, hostResolveTimeout)
+				if err := u.update(resolveCtx); err != nil && ctx.Err() == nil {
+					u.slogger.Warn("failed to resolve cadence frontends - the current frontends are used", slog.String("error", err.Error()))
+				}
+				cancel()
+			}
+		}
+	}()
+	return nil
+}
+
+func (u *peerUpdater) Stop() error {
+	if u.cancel != nil {
+		u.cancel()
+		<-u.done
+	}
+	return nil
+}
+
+func (u *peerUpdater) IsRunning() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.current != nil
+}
+
+// update resolves the host:port list and applies the added and removed addresses to the peer list. If no host can be
+// resolved the peer list is not changed.
+func (u *peerUpdater) update(ctx context.Context) error {
+	addresses, err := u.resolveAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var updates peer.ListUpdates
+	for _, a := range addresses {
+		if !slices.Contains(u.current, a) {
+			updates.Additions = append(updates.Additions, hostport.Identify(a))
+		}
+	}
+	for _, a := range u.current {
+		if !slices.Contains(addresses, a) {
+			updates.Removals = append(updates.Removals, hostport.Identify(a))
+		}
+	}
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+		return nil
+	}
+	if err = u.list.Update(updates); err != nil {
+		return errors.Wrap(err, "failed to update cadence frontend peers")
+	}
+	u.current = addresses
+	u.slogger.Info("cadence frontends updated", slog.Any("frontends", addresses))
+	return nil
+}
+
+// resolveAll returns the sorted addresses of the host:port list - IP addresses are used as is. A host which can not be
+// resolved keeps its last resolved addresses (or is skipped); it fails only if no address is found.
+func (u *peerUpdater) resolveAll(ctx context.Context) ([]string, error) {
+	var addresses []string
+	var lastErr error
+	for _, hp := range u.hostPorts {
+		host, port, err := net.SplitHostPort(hp)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad cadence frontend address %s", hp)
+		}
+		if net.ParseIP(host) != nil {
+			addresses = append(addresses, hp)
+			continue
+		}
+		ips, err := u.resolve(ctx, host)
+		if err != nil {
+			lastErr = errors.Wrap(err, "failed to resolve cadence frontend host %s", host)
+			u.slogger.Warn("failed to resolve cadence frontend host - last resolved addresses are used",
+				slog.String("host", host), slog.Any("addresses", u.resolved[hp]), slog.String("error", err.Error()))
+			addresses = append(addresses, u.resolved[hp]...)
+			continue
+		}
+		var resolved []string
+		for _, ip := range ips {
+			resolved = append(resolved, net.JoinHostPort(ip, port))
+		}
+		if u.resolved == nil {
+			u.resolved = map[string][]string{}
+		}
+		u.resolved[hp] = resolved
+		addresses = append(addresses, resolved...)
+	}
+	if len(addresses) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, errors.New("no cadence frontend address found in %s", strings.Join(u.hostPorts, ","))
+	}
+	sort.Strings(addresses)
+	return slices.Compact(addresses), nil
+}
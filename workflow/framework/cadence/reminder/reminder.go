@@ -0,0 +1,220 @@
+// Package reminder calls back at a given time with a payload: "call me back at time T with payload P".
+//
+// Every reminder is a timer workflow (workflow id "gox-reminder:<id>") on the configured task list. When it fires,
+// the reminder is dispatched to a registered Go handler or POSTed to an HTTP callback (signed like notify webhooks),
+// with retries.
+//
+//	reminders := reminder.NewService(api, "server_1_ts_1",
+//		reminder.WithHandler("order-expiry", expireOrder),
+//		reminder.WithSigningSecret(os.Getenv("CALLBACK_SECRET")),
+//	)
+//	reminders.Register() // in the processes running workers for the task list
+//
+//	err := reminders.ScheduleReminder(ctx, "order-42", time.Now().Add(30*time.Minute), order, reminder.Target{Handler: "order-expiry"})
+//	err = reminders.RescheduleReminder(ctx, "order-42", time.Now().Add(time.Hour))
+//	err = reminders.CancelReminder(ctx, "order-42")
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	gocadence "go.uber.org/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"net/http"
+	"time"
+)
+
+const (
+	// WorkflowName is the timer workflow of a reminder
+	WorkflowName = "gox.reminder.Timer"
+
+	// ActivityName is the activity which dispatches a fired reminder
+	ActivityName = "gox.reminder.Dispatch"
+
+	// QueryReminder returns the Reminder
+	QueryReminder = "gox_reminder"
+
+	// ReasonUnknownHandler is the (non-retryable) custom error reason when the handler is not registered
+	ReasonUnknownHandler = "gox.reminder.UnknownHandler"
+
+	workflowIDPrefix = "gox-reminder:"
+	signalReschedule = "gox_reminder_reschedule"
+)
+
+// Target is where a reminder is dispatched - exactly one of Handler and URL must be set
+type Target struct {
+	// Handler is the name of a handler registered with WithHandler
+	Handler string `json:"handler,omitempty"`
+
+	// URL is POSTed the Reminder as JSON
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Reminder is given to the handler or callback when it fires
+type Reminder struct {
+	ID          string          `json:"id"`
+	FireAt      time.Time       `json:"fire_at"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Target      Target          `json:"target"`
+}
+
+// Handler handles a fired reminder. Returning an error retries it as per the retry policy.
+type Handler func(ctx context.Context, reminder Reminder) error
+
+// Service schedules reminders and dispatches them when they fire
+type Service struct {
+	api         cadence.Api
+	taskList    string
+	handlers    map[string]Handler
+	httpClient  *http.Client
+	secret      string
+	retryPolicy gocadence.RetryPolicy
+	timeout     time.Duration
+}
+
+// Option configures the Service
+type Option func(s *Service)
+
+// WithHandler registers a handler for Target.Handler
+func WithHandler(name string, handler Handler) Option {
+	return func(s *Service) {
+		s.handlers[name] = handler
+	}
+}
+
+// WithHTTPClient sets the http client used for callbacks
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(s *Service) {
+		s.httpClient = httpClient
+	}
+}
+
+// WithSigningSecret signs callbacks with HMAC-SHA256 - verify them with notify.VerifyRequest
+func WithSigningSecret(secret string) Option {
+	return func(s *Service) {
+		s.secret = secret
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the dispatch (default: 1s doubled up to 1m, for 24h)
+func WithRetryPolicy(policy gocadence.RetryPolicy) Option {
+	return func(s *Service) {
+		s.retryPolicy = policy
+	}
+}
+
+// WithDispatchTimeout sets the timeout of one dispatch attempt (default 30s)
+func WithDispatchTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// NewService creates a reminder service which runs the timers on the task list
+func NewService(api cadence.Api, taskList string, opts ...Option) *Service {
+	s := &Service{
+		api:        api,
+		taskList:   taskList,
+		handlers:   map[string]Handler{},
+		httpClient: http.DefaultClient,
+		retryPolicy: gocadence.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    time.Minute,
+			ExpirationInterval: 24 * time.Hour,
+		},
+		timeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.retryPolicy.NonRetriableErrorReasons = append(s.retryPolicy.NonRetriableErrorReasons, ReasonUnknownHandler)
+	return s
+}
+
+// Register registers the timer workflow and the dispatch activity
+func (s *Service) Register() {
+	workflow.RegisterWithOptions(s.timerWorkflow, workflow.RegisterOptions{Name: WorkflowName})
+	activity.RegisterWithOptions(s.dispatch, activity.RegisterOptions{Name: ActivityName})
+}
+
+// ScheduleReminder schedules a reminder. It fails if a reminder with the same id is pending.
+func (s *Service) ScheduleReminder(ctx context.Context, id string, fireAt time.Time, payload interface{}, target Target) error {
+	if len(id) == 0 {
+		return errors.New("reminder id is required")
+	}
+	if (len(target.Handler) == 0) == (len(target.URL) == 0) {
+		return errors.New("reminder target must have exactly one of handler and url")
+	}
+
+	reminder := Reminder{ID: id, FireAt: fireAt, ScheduledAt: time.Now(), Target: target}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "failed to serialize reminder payload: %s", id)
+		}
+		reminder.Payload = data
+	}
+
+	_, err := s.api.StartWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                              workflowIDPrefix + id,
+		TaskList:                        s.taskList,
+		ExecutionStartToCloseTimeout:    s.executionTimeout(time.Until(fireAt)),
+		DecisionTaskStartToCloseTimeout: 10 * time.Second,
+		WorkflowIDReusePolicy:           client.WorkflowIDReusePolicyAllowDuplicate,
+	}, WorkflowName, reminder)
+
+	var alreadyStarted *shared.WorkflowExecutionAlreadyStartedError
+	if err != nil && errors.As(err, &alreadyStarted) {
+		return errors.Wrap(err, "reminder is already scheduled: %s", id)
+	} else if err != nil {
+		return errors.Wrap(err, "failed to schedule reminder: %s", id)
+	}
+	return nil
+}
+
+// RescheduleReminder changes the fire time of a pending reminder. If the reminder fired and is being dispatched, it is
+// scheduled again for the new fire time after the dispatch. It fails if the reminder is already dispatched (or
+// cancelled).
+func (s *Service) RescheduleReminder(ctx context.Context, id string, fireAt time.Time) error {
+	ctx = context.WithValue(ctx, cadence.TaskListForAction, s.taskList)
+	if err := s.api.SignalWorkflow(ctx, workflowIDPrefix+id, "", signalReschedule, fireAt); err != nil {
+		return errors.Wrap(err, "failed to reschedule reminder: %s", id)
+	}
+	return nil
+}
+
+// CancelReminder cancels a pending reminder
+func (s *Service) CancelReminder(ctx context.Context, id string) error {
+	ctx = context.WithValue(ctx, cadence.TaskListForAction, s.taskList)
+	if err := s.api.CancelWorkflow(ctx, workflowIDPrefix+id, ""); err != nil {
+		return errors.Wrap(err, "failed to cancel reminder: %s", id)
+	}
+	return nil
+}
+
+// GetReminder returns a pending reminder
+func (s *Service) GetReminder(ctx context.Context, id string) (*Reminder, error) {
+	ctx = context.WithValue(ctx, cadence.TaskListForAction, s.taskList)
+	value, err := s.api.QueryWorkflow(ctx, workflowIDPrefix+id, "", QueryReminder)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get reminder: %s", id)
+	}
+	reminder := &Reminder{}
+	if err := value.Get(reminder); err != nil {
+		return nil, errors.Wrap(err, "failed to read reminder: %s", id)
+	}
+	return reminder, nil
+}
+
+// executionTimeout is the time until the reminder fires plus the time for the dispatch retries
+func (s *Service) executionTimeout(untilFire time.Duration) time.Duration {
+	return max(untilFire, 0) + s.retryPolicy.ExpirationInterval + s.timeout + time.Hour
+}
@@ -0,0 +1,118 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence/notify"
+	gocadence "go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// timerWorkflow waits until the reminder fires and dispatches it. A reschedule continues as new with the new fire
+// time, so the history stays small and the execution timeout fits the new time. A reschedule which comes after the
+// timer fired (while the reminder is dispatched) schedules the reminder again after the dispatch.
+func (s *Service) timerWorkflow(ctx workflow.Context, reminder Reminder) error {
+	if err := workflow.SetQueryHandler(ctx, QueryReminder, func() (Reminder, error) {
+		return reminder, nil
+	}); err != nil {
+		return err
+	}
+
+	reschedule := workflow.GetSignalChannel(ctx, signalReschedule)
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	timer := workflow.NewTimer(timerCtx, max(reminder.FireAt.Sub(workflow.Now(ctx)), 0))
+
+	var fireAt time.Time
+	rescheduled := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(reschedule, func(c workflow.Channel, more bool) {
+		c.Receive(ctx, &fireAt)
+		rescheduled = true
+	})
+	selector.AddFuture(timer, func(f workflow.Future) {})
+	selector.Select(ctx)
+
+	continueAsNew := func() error {
+		// Take the last one if more reschedules came together
+		for reschedule.ReceiveAsync(&fireAt) {
+		}
+		reminder.FireAt = fireAt
+		ctx = workflow.WithExecutionStartToCloseTimeout(ctx, s.executionTimeout(fireAt.Sub(workflow.Now(ctx))))
+		return workflow.NewContinueAsNewError(ctx, WorkflowName, reminder)
+	}
+	if rescheduled {
+		cancelTimer()
+		return continueAsNew()
+	}
+
+	// Cancelled by CancelReminder
+	if err := timer.Get(ctx, nil); err != nil {
+		return err
+	}
+
+	activityCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    s.timeout,
+		RetryPolicy:            &s.retryPolicy,
+	})
+	err := workflow.ExecuteActivity(activityCtx, ActivityName, reminder).Get(activityCtx, nil)
+	if reschedule.ReceiveAsync(&fireAt) {
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("reminder dispatch failed - it is rescheduled", zap.Error(err))
+		}
+		return continueAsNew()
+	}
+	return err
+}
+
+// dispatch sends the fired reminder to the handler or the callback url
+func (s *Service) dispatch(ctx context.Context, reminder Reminder) error {
+	if len(reminder.Target.Handler) > 0 {
+		handler, ok := s.handlers[reminder.Target.Handler]
+		if !ok {
+			return gocadence.NewCustomError(ReasonUnknownHandler, reminder.Target.Handler)
+		}
+		return handler(ctx, reminder)
+	}
+	return s.post(ctx, reminder)
+}
+
+func (s *Service) post(ctx context.Context, reminder Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize reminder: %s", reminder.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reminder.Target.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create reminder callback request: %s", reminder.Target.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range reminder.Target.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(notify.HeaderTimestamp, timestamp)
+		req.Header.Set(notify.HeaderSignature, notify.Sign(s.secret, timestamp, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call reminder callback url: %s", reminder.Target.URL)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("reminder callback url %s returned status %d", reminder.Target.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package replayer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// History is a workflow history to replay
+type History struct {
+	// Source is the file name or "<workflowID>/<runID>" for a history fetched from cadence
+	Source       string
+	WorkflowType string
+	History      *shared.History
+}
+
+// LoadHistoryFile loads a history from a JSON file. Both the format of "cadence workflow show -of <file>" (array of
+// events) and {"events": [...]} are supported.
+func LoadHistoryFile(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read history file %s", path)
+	}
+
+	history := &shared.History{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &history.Events)
+	} else {
+		err = json.Unmarshal(trimmed, history)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid history json in file %s", path)
+	}
+	return newHistory(path, history), nil
+}
+
+// LoadHistoryDir loads all the "*.json" history files in the directory, sorted by file name
+func LoadHistoryDir(dir string) ([]*History, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list history files in %s", dir)
+	}
+	sort.Strings(files)
+
+	histories := make([]*History, 0, len(files))
+	for _, file := range files {
+		h, err := LoadHistoryFile(file)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, h)
+	}
+	return histories, nil
+}
+
+// FetchHistory fetches the history of a workflow execution from cadence using the wrapper
+func FetchHistory(ctx context.Context, api cadence.Api, ref cadence.ExecutionRef) (*History, error) {
+	ctx = context.WithValue(ctx, cadence.TaskListForAction, ref.TaskList)
+	history, err := api.GetWorkflowHistory(ctx, ref.WorkflowID, ref.RunID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch history - workflowID=%s runID=%s", ref.WorkflowID, ref.RunID)
+	}
+	return newHistory(ref.WorkflowID+"/"+ref.RunID, history), nil
+}
+
+// FetchHistories fetches the histories of the workflow executions matching the visibility query (e.g.
+// "WorkflowType = 'OrderWorkflow' AND CloseTime = missing"). The task list is used to pick the worker group (domain).
+// At most limit histories are fetched if limit > 0.
+func FetchHistories(ctx context.Context, api cadence.Api, taskList string, query string, limit int) ([]*History, error) {
+	listCtx := context.WithValue(ctx, cadence.TaskListForAction, taskList)
+
+	var histories []*History
+	var nextPageToken []byte
+	for {
+		request := &shared.ListWorkflowExecutionsRequest{Query: &query, NextPageToken: nextPageToken}
+		response, err := api.ListWorkflow(listCtx, request)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list workflows - query=%s", query)
+		}
+
+		for _, execution := range response.Executions {
+			ref := cadence.ExecutionRef{
+				TaskList:   taskList,
+				WorkflowID: execution.GetExecution().GetWorkflowId(),
+				RunID:      execution.GetExecution().GetRunId(),
+			}
+			h, err := FetchHistory(ctx, api, ref)
+			if err != nil {
+				return nil, err
+			}
+			histories = append(histories, h)
+			if limit > 0 && len(histories) >= limit {
+				return histories, nil
+			}
+		}
+
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			return histories, nil
+		}
+	}
+}
+
+func newHistory(source string, history *shared.History) *History {
+	h := &History{Source: source, History: history}
+	if len(history.Events) > 0 {
+		if attr := history.Events[0].WorkflowExecutionStartedEventAttributes; attr != nil {
+			h.WorkflowType = attr.GetWorkflowType().GetName()
+		}
+	}
+	if len(h.WorkflowType) == 0 {
+		h.WorkflowType = "unknown"
+	}
+	return h
+}
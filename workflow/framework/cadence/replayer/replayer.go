@@ -0,0 +1,163 @@
+// Package replayer replays recorded workflow histories against the workflow functions registered in this binary. It
+// is used to catch non-deterministic workflow changes before they are deployed.
+//
+// Histories are loaded from JSON files (downloaded with "cadence workflow show -of <file>") or fetched from a live
+// domain using the cadence wrapper:
+//
+//	histories, err := replayer.LoadHistoryDir("testdata/histories")
+//	report := replayer.New().ReplayAll(histories)
+//	if report.Failed() {
+//		fmt.Println(report)
+//	}
+//
+// Use RunHistoryDir in tests to replay every history file in a directory as a sub test.
+package replayer
+
+import (
+	"fmt"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+)
+
+// Replayer replays workflow histories using cadence's WorkflowReplayer. Workflows registered with workflow.Register
+// (global registry) are available to the replayer, others can be registered with RegisterWorkflowWithOptions.
+type Replayer struct {
+	replayer worker.WorkflowReplayer
+	logger   *zap.Logger
+}
+
+// Option is used to customise the replayer created by New
+type Option func(r *replayerOptions)
+
+type replayerOptions struct {
+	logger        *zap.Logger
+	replayOptions worker.ReplayOptions
+}
+
+// WithLogger sets the logger used by cadence during replay (default no-op logger)
+func WithLogger(logger *zap.Logger) Option {
+	return func(r *replayerOptions) {
+		r.logger = logger
+	}
+}
+
+// WithReplayOptions sets the replay options e.g. data converter or workflow interceptors used by the workers
+func WithReplayOptions(options worker.ReplayOptions) Option {
+	return func(r *replayerOptions) {
+		r.replayOptions = options
+	}
+}
+
+// New creates a replayer
+func New(opts ...Option) *Replayer {
+	o := &replayerOptions{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Replayer{
+		replayer: worker.NewWorkflowReplayerWithOptions(o.replayOptions),
+		logger:   o.logger,
+	}
+}
+
+// RegisterWorkflowWithOptions registers a workflow function only for this replayer
+func (r *Replayer) RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions) {
+	r.replayer.RegisterWorkflowWithOptions(w, options)
+}
+
+// Result is the result of replaying a single history
+type Result struct {
+	Source       string
+	WorkflowType string
+	Err          error
+
+	// NonDeterministic is true if the replay failed because the workflow code does not match the history
+	NonDeterministic bool
+}
+
+// Replay replays a single history
+func (r *Replayer) Replay(h *History) Result {
+	result := Result{Source: h.Source, WorkflowType: h.WorkflowType}
+	result.Err = r.replayer.ReplayWorkflowHistory(r.logger, h.History)
+	result.NonDeterministic = IsNonDeterministicError(result.Err)
+	return result
+}
+
+// ReplayAll replays all the histories and returns a report grouped by workflow type
+func (r *Replayer) ReplayAll(histories []*History) *Report {
+	report := &Report{WorkflowTypes: map[string]*WorkflowTypeReport{}}
+	for _, h := range histories {
+		report.add(r.Replay(h))
+	}
+	return report
+}
+
+// IsNonDeterministicError returns true if the replay error is caused by a non-deterministic workflow change
+func IsNonDeterministicError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "nondeterministic")
+}
+
+// Report is the result of replaying many histories
+type Report struct {
+	Results       []Result
+	WorkflowTypes map[string]*WorkflowTypeReport
+}
+
+// WorkflowTypeReport is the replay summary of a workflow type
+type WorkflowTypeReport struct {
+	WorkflowType     string
+	Replayed         int
+	Failed           int
+	NonDeterministic int
+	Failures         []Result
+}
+
+func (r *Report) add(result Result) {
+	r.Results = append(r.Results, result)
+
+	wt, ok := r.WorkflowTypes[result.WorkflowType]
+	if !ok {
+		wt = &WorkflowTypeReport{WorkflowType: result.WorkflowType}
+		r.WorkflowTypes[result.WorkflowType] = wt
+	}
+	wt.Replayed++
+	if result.Err != nil {
+		wt.Failed++
+		wt.Failures = append(wt.Failures, result)
+	}
+	if result.NonDeterministic {
+		wt.NonDeterministic++
+	}
+}
+
+// Failed returns true if any of the histories failed to replay
+func (r *Report) Failed() bool {
+	for _, wt := range r.WorkflowTypes {
+		if wt.Failed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a human readable report - one line per workflow type followed by the failures
+func (r *Report) String() string {
+	names := make([]string, 0, len(r.WorkflowTypes))
+	for name := range r.WorkflowTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb := &strings.Builder{}
+	for _, name := range names {
+		wt := r.WorkflowTypes[name]
+		_, _ = fmt.Fprintf(sb, "%s: replayed=%d failed=%d nondeterministic=%d\n", name, wt.Replayed, wt.Failed, wt.NonDeterministic)
+		for _, f := range wt.Failures {
+			_, _ = fmt.Fprintf(sb, "  %s: %v\n", f.Source, f.Err)
+		}
+	}
+	return sb.String()
+}
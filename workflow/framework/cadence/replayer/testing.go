@@ -0,0 +1,39 @@
+package replayer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RunHistoryDir replays every "*.json" history file in the directory as a sub test of t. The sub test name is the file
+// name without the extension.
+//
+//	func TestReplay(t *testing.T) {
+//		workflow.Register(OrderWorkflow)
+//		replayer.RunHistoryDir(t, "testdata/histories")
+//	}
+func RunHistoryDir(t *testing.T, dir string, opts ...Option) {
+	t.Helper()
+
+	histories, err := LoadHistoryDir(dir)
+	if err != nil {
+		t.Fatalf("failed to load histories: %v", err)
+	}
+	if len(histories) == 0 {
+		t.Fatalf("no history file found in %s", dir)
+	}
+
+	r := New(opts...)
+	for _, h := range histories {
+		name := strings.TrimSuffix(filepath.Base(h.Source), filepath.Ext(h.Source))
+		t.Run(name, func(t *testing.T) {
+			result := r.Replay(h)
+			if result.NonDeterministic {
+				t.Fatalf("non-deterministic change in workflow %s: %v", result.WorkflowType, result.Err)
+			} else if result.Err != nil {
+				t.Fatalf("failed to replay workflow %s: %v", result.WorkflowType, result.Err)
+			}
+		})
+	}
+}
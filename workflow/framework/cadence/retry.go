@@ -0,0 +1,398 @@
+package cadence
+
+import (
+	"context"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/yarpc/yarpcerrors"
+	"log/slog"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Operations of the Api calls - they are used as keys of ClientConfig.Operations and as the "operation" metric tag
+const (
+	OperationStartWorkflow             = "start_workflow"
+	OperationExecuteWorkflow           = "execute_workflow"
+	OperationCancelWorkflow            = "cancel_workflow"
+	OperationQueryWorkflow             = "query_workflow"
+	OperationSignalWorkflow            = "signal_workflow"
+	OperationTerminateWorkflow         = "terminate_workflow"
+	OperationGetWorkflowHistory        = "get_workflow_history"
+	OperationListWorkflow              = "list_workflow"
+	OperationDescribeWorkflowExecution = "describe_workflow_execution"
+	OperationResetWorkflow             = "reset_workflow"
+)
+
+// Circuit breaker states reported by Health
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerOpen     = "open"
+	CircuitBreakerDisabled = "disabled"
+)
+
+// ClientConfig is the retry policy and the circuit breaker of the Api calls. They are off by default.
+type ClientConfig struct {
+	// Retry is the retry policy of all the operations
+	Retry *RetryPolicy `json:"retry" yaml:"retry"`
+
+	// Operations overrides the retry policy of an operation e.g. "start_workflow" (see the Operation constants)
+	Operations map[string]*RetryPolicy `json:"operations" yaml:"operations"`
+
+	// CircuitBreaker is the circuit breaker of each worker group
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
+}
+
+// RetryPolicy retries the Api calls which fail with a retryable error (see IsRetryableError). The wait before the
+// n-th retry is initial_interval * backoff_coefficient^(n-1), capped by max_interval, with a random jitter.
+//
+// The cadence client retries transient errors inside a call until its context is done - attempt_timeout bounds each
+// attempt so the retries of this policy are used.
+//
+// Retries of start and signal can run the call twice if the first attempt reached cadence but timed out - a started
+// workflow is reported as WorkflowExecutionAlreadyStartedError.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first call (default 3, 1 = no retry)
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts"`
+
+	// InitialInterval is the wait before the first retry (default 100ms)
+	InitialInterval time.Duration `json:"initial_interval" yaml:"initial_interval"`
+
+	// MaxInterval caps the wait between the retries (default 5s)
+	MaxInterval time.Duration `json:"max_interval" yaml:"max_interval"`
+
+	// BackoffCoefficient is the growth of the wait between retries (default 2)
+	BackoffCoefficient float64 `json:"backoff_coefficient" yaml:"backoff_coefficient"`
+
+	// Jitter is the fraction of the wait that is random e.g. 0.2 waits between 80% and 120%. Not set is the default
+	// 0.2; 0 turns the jitter off.
+	Jitter *float64 `json:"jitter" yaml:"jitter"`
+
+	// AttemptTimeout is the timeout of each attempt (default 10s)
+	AttemptTimeout time.Duration `json:"attempt_timeout" yaml:"attempt_timeout"`
+}
+
+// CircuitBreakerConfig is the hystrix circuit breaker of a worker group. Only retryable errors count as failures.
+type CircuitBreakerConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Timeout of a call - the call fails with hystrix.ErrTimeout after it (default 1m)
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// MaxConcurrentRequests are the calls allowed at the same time (default 1000)
+	MaxConcurrentRequests int `json:"max_concurrent_requests" yaml:"max_concurrent_requests"`
+
+	// RequestVolumeThreshold is the minimum number of calls in 10s before the circuit can open (default 20)
+	RequestVolumeThreshold int `json:"request_volume_threshold" yaml:"request_volume_threshold"`
+
+	// SleepWindow is how long the circuit stays open before a call is tried again (default 5s)
+	SleepWindow time.Duration `json:"sleep_window" yaml:"sleep_window"`
+
+	// ErrorPercentThreshold is the percent of failed calls which opens the circuit (default 50)
+	ErrorPercentThreshold int `json:"error_percent_threshold" yaml:"error_percent_threshold"`
+}
+
+// WorkerGroupHealth is the health of a worker group returned by Health
+type WorkerGroupHealth struct {
+	Name           string `json:"name"`
+	Domain         string `json:"domain"`
+	Cluster        string `json:"cluster,omitempty"`
+	CircuitBreaker string `json:"circuit_breaker"`
+	Healthy        bool   `json:"healthy"`
+}
+
+// IsRetryableError returns true for the transient errors of the cadence frontend: ServiceBusyError,
+// InternalServiceError, timeouts and unavailable frontends
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var serviceBusy *shared.ServiceBusyError
+	var internalService *shared.InternalServiceError
+	if errors.As(err, &serviceBusy) || errors.As(err, &internalService) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeDeadlineExceeded, yarpcerrors.CodeUnavailable, yarpcerrors.CodeResourceExhausted:
+		return true
+	}
+	return false
+}
+
+var operations = []string{
+	OperationStartWorkflow, OperationExecuteWorkflow, OperationCancelWorkflow, OperationQueryWorkflow,
+	OperationSignalWorkflow, OperationTerminateWorkflow, OperationGetWorkflowHistory, OperationListWorkflow,
+	OperationDescribeWorkflowExecution, OperationResetWorkflow,
+}
+
+// validate checks the retry policies and the circuit breaker and applies the defaults
+func (c *ClientConfig) validate(r *ValidationReport, path string) {
+	c.Retry.validate(r, path+".retry")
+	for _, operation := range sortedKeys(c.Operations) {
+		if !slices.Contains(operations, operation) {
+			r.errorf(path+".operations."+operation, "unknown operation %s - must be one of %s", operation, strings.Join(operations, ", "))
+			continue
+		}
+		c.Operations[operation].validate(r, path+".operations."+operation)
+	}
+
+	b := c.CircuitBreaker
+	if b == nil || !b.Enabled {
+		return
+	}
+	path += ".circuit_breaker"
+	durations := []struct {
+		name  string
+		value *time.Duration
+		def   time.Duration
+	}{
+		{"timeout", &b.Timeout, time.Minute},
+		{"sleep_window", &b.SleepWindow, 5 * time.Second},
+	}
+	for _, d := range durations {
+		switch {
+		case *d.value < 0:
+			r.errorf(path+"."+d.name, "%s must not be negative - found %s", d.name, *d.value)
+		case *d.value == 0:
+			*d.value = d.def
+			r.applied(path+"."+d.name, d.def)
+		}
+	}
+	sizes := []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{"max_concurrent_requests", &b.MaxConcurrentRequests, 1000},
+		{"request_volume_threshold", &b.RequestVolumeThreshold, 20},
+		{"error_percent_threshold", &b.ErrorPercentThreshold, 50},
+	}
+	for _, s := range sizes {
+		switch {
+		case *s.value < 0:
+			r.errorf(path+"."+s.name, "%s must not be negative - found %d", s.name, *s.value)
+		case *s.value == 0:
+			*s.value = s.def
+			r.applied(path+"."+s.name, s.def)
+		}
+	}
+	if b.ErrorPercentThreshold > 100 {
+		r.errorf(path+".error_percent_threshold", "error_percent_threshold must be in range [1, 100] - found %d", b.ErrorPercentThreshold)
+	}
+}
+
+// validate checks the retry policy and applies the defaults
+func (p *RetryPolicy) validate(r *ValidationReport, path string) {
+	if p == nil {
+		return
+	}
+	if p.MaxAttempts < 0 {
+		r.errorf(path+".max_attempts", "max_attempts must not be negative - found %d", p.MaxAttempts)
+	}
+	if p.InitialInterval < 0 || p.MaxInterval < 0 || p.AttemptTimeout < 0 {
+		r.errorf(path, "initial_interval, max_interval and attempt_timeout must not be negative")
+	}
+	if p.BackoffCoefficient != 0 && p.BackoffCoefficient < 1 {
+		r.errorf(path+".backoff_coefficient", "backoff_coefficient must be at least 1 - found %v", p.BackoffCoefficient)
+	}
+	if p.Jitter != nil && (*p.Jitter < 0 || *p.Jitter > 1) {
+		r.errorf(path+".jitter", "jitter must be in range [0, 1] - found %v", *p.Jitter)
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 3
+		r.applied(path+".max_attempts", p.MaxAttempts)
+	}
+	if p.InitialInterval == 0 {
+		p.InitialInterval = 100 * time.Millisecond
+		r.applied(path+".initial_interval", p.InitialInterval)
+	}
+	if p.MaxInterval == 0 {
+		p.MaxInterval = 5 * time.Second
+		r.applied(path+".max_interval", p.MaxInterval)
+	}
+	if p.BackoffCoefficient == 0 {
+		p.BackoffCoefficient = 2
+		r.applied(path+".backoff_coefficient", p.BackoffCoefficient)
+	}
+	if p.Jitter == nil {
+		jitter := 0.2
+		p.Jitter = &jitter
+		r.applied(path+".jitter", jitter)
+	}
+	if p.AttemptTimeout == 0 {
+		p.AttemptTimeout = 10 * time.Second
+		r.applied(path+".attempt_timeout", p.AttemptTimeout)
+	}
+}
+
+// retryPolicy returns the retry policy of the operation (nil if the operation is not retried)
+func (c *ClientConfig) retryPolicy(operation string) *RetryPolicy {
+	if p, ok := c.Operations[operation]; ok {
+		return p
+	}
+	return c.Retry
+}
+
+// backoff returns the wait before the retry (attempt 1 is the first retry)
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	wait := float64(p.InitialInterval) * math.Pow(p.BackoffCoefficient, float64(attempt-1))
+	wait = math.Min(wait, float64(p.MaxInterval))
+	if p.Jitter != nil && *p.Jitter > 0 {
+		wait += wait * *p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// commandName is the hystrix command of the worker group
+func commandName(workerGroup string) string {
+	return "gox_cadence_" + workerGroup
+}
+
+// configureCircuitBreaker sets the hystrix command of the worker group
+func configureCircuitBreaker(workerGroup string, c *CircuitBreakerConfig) {
+	if c == nil || !c.Enabled {
+		return
+	}
+	hystrix.ConfigureCommand(commandName(workerGroup), hystrix.CommandConfig{
+		Timeout:                int(c.Timeout / time.Millisecond),
+		MaxConcurrentRequests:  c.MaxConcurrentRequests,
+		RequestVolumeThreshold: c.RequestVolumeThreshold,
+		SleepWindow:            int(c.SleepWindow / time.Millisecond),
+		ErrorPercentThreshold:  c.ErrorPercentThreshold,
+	})
+}
+
+// call runs the Api call of the operation with the retry policy of the operation and the circuit breaker of the
+// worker group
+func (w *cadenceWorker) call(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	_, err := callWithResult(ctx, w, operation, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// callWithResult is call for the Api calls with a result. The result is returned (not captured by fn) because a
+// call abandoned by the circuit breaker timeout keeps running.
+func callWithResult[T any](ctx context.Context, w *cadenceWorker, operation string, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy := w.client.retryPolicy(operation)
+	attempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		attempts = policy.MaxAttempts
+	}
+	scope := w.clientScope().Tagged(map[string]string{"operation": operation})
+
+	var result T
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return result, err
+			case <-time.After(policy.backoff(attempt)):
+			}
+			scope.Counter("gox_client_retry").Inc(1)
+		}
+
+		result, err = callWithCircuitBreaker(ctx, w, func(ctx context.Context) (T, error) {
+			if policy != nil && policy.AttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, policy.AttemptTimeout)
+				defer cancel()
+			}
+			return fn(ctx)
+		})
+		if err == nil || !IsRetryableError(err) || ctx.Err() != nil {
+			break
+		}
+		w.slogger.Debug("cadence call failed with retryable error", slog.String("operation", operation), slog.Int("attempt", attempt+1), slog.String("error", err.Error()))
+	}
+	if err != nil {
+		scope.Counter("gox_client_failed").Inc(1)
+	}
+	return result, err
+}
+
+// callWithCircuitBreaker runs the call in the hystrix command of the worker group. Errors which are not retryable
+// (e.g. workflow not found) do not count as failures of the circuit. The attempt timeout is applied inside the
+// command because hystrix does not count an expired context of the command as a failure.
+func callWithCircuitBreaker[T any](ctx context.Context, w *cadenceWorker, fn func(ctx context.Context) (T, error)) (T, error) {
+	if w.client.CircuitBreaker == nil || !w.client.CircuitBreaker.Enabled {
+		return fn(ctx)
+	}
+
+	// The channel has room for the result so an abandoned call does not block
+	type outcome struct {
+		result T
+		err    error
+	}
+	ch := make(chan outcome, 1)
+	err := hystrix.DoC(ctx, commandName(w.workerGroup.Name), func(ctx context.Context) error {
+		result, err := fn(ctx)
+		ch <- outcome{result: result, err: err}
+		if IsRetryableError(err) {
+			// Wrapped so a context error of the attempt is reported as a failure (not as a context error)
+			return errors.Wrap(err, "retryable cadence error")
+		}
+		return nil
+	}, nil)
+	w.updateCircuitBreakerMetric()
+
+	select {
+	case o := <-ch:
+		return o.result, o.err
+	default:
+	}
+	var zero T
+	if errors.As(err, new(hystrix.CircuitError)) {
+		return zero, errors.Wrap(err, "cadence circuit breaker rejected the call - workerGroup=%s", w.workerGroup.Name)
+	}
+	return zero, err
+}
+
+// circuitBreakerState returns the state of the circuit breaker of the worker group
+func (w *cadenceWorker) circuitBreakerState() string {
+	if w.client.CircuitBreaker == nil || !w.client.CircuitBreaker.Enabled {
+		return CircuitBreakerDisabled
+	}
+	if circuit, _, err := hystrix.GetCircuit(commandName(w.workerGroup.Name)); err == nil && circuit.IsOpen() {
+		return CircuitBreakerOpen
+	}
+	return CircuitBreakerClosed
+}
+
+func (w *cadenceWorker) updateCircuitBreakerMetric() {
+	value := 0.0
+	if w.circuitBreakerState() == CircuitBreakerOpen {
+		value = 1
+	}
+	w.clientScope().Gauge("gox_client_circuit_open").Update(value)
+}
+
+// clientScope is the metric scope of the Api calls of the worker group
+func (w *cadenceWorker) clientScope() tally.Scope {
+	return w.tallyScope.Tagged(map[string]string{"worker_group": w.workerGroup.Name, "domain": w.workerGroup.Domain})
+}
+
+func (wrapper *cadenceWrapperImpl) Health(ctx context.Context) ([]WorkerGroupHealth, error) {
+	result := make([]WorkerGroupHealth, 0)
+	for _, g := range wrapper.groups() {
+		state := g.circuitBreakerState()
+		result = append(result, WorkerGroupHealth{
+			Name:           g.workerGroup.Name,
+			Domain:         g.workerGroup.Domain,
+			Cluster:        g.cluster,
+			CircuitBreaker: state,
+			Healthy:        state != CircuitBreakerOpen,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,243 @@
+// Package saga runs compensations inside a workflow function when a later step fails or the workflow is cancelled.
+//
+// Register a compensation after every activity which completed, and call Compensate when the workflow fails:
+//
+//	func PaymentWorkflow(ctx workflow.Context, in Payment) (err error) {
+//		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{...})
+//		s := saga.New(saga.WithRetryPolicy(&cadence.RetryPolicy{...}))
+//		defer func() {
+//			if err != nil {
+//				err = s.Compensate(ctx, err)
+//			}
+//		}()
+//
+//		var hold HoldResult
+//		if err = workflow.ExecuteActivity(ctx, HoldFunds, in).Get(ctx, &hold); err != nil {
+//			return err
+//		}
+//		s.AddCompensation(ReleaseFunds, hold.ID)
+//
+//		return workflow.ExecuteActivity(ctx, Charge, in).Get(ctx, nil)
+//	}
+package saga
+
+import (
+	"fmt"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// compensation is a registered compensation step
+type compensation struct {
+	name string
+	fn   func(ctx workflow.Context) error
+}
+
+// Saga keeps the compensations of the completed steps. It must only be used from the workflow function (it is not
+// safe to use from multiple workflow.Go coroutines at the same time).
+type Saga struct {
+	compensations   []compensation
+	parallel        bool
+	continueOnError bool
+	activityOptions *workflow.ActivityOptions
+	retryPolicy     *cadence.RetryPolicy
+	compensated     bool
+}
+
+// Option configures the Saga
+type Option func(s *Saga)
+
+// WithParallelCompensation runs all compensations at the same time instead of in reverse order
+func WithParallelCompensation() Option {
+	return func(s *Saga) {
+		s.parallel = true
+	}
+}
+
+// WithStopOnError stops running compensations (in reverse order) after the first failed compensation. By default all
+// compensations are run and all failures are reported.
+func WithStopOnError() Option {
+	return func(s *Saga) {
+		s.continueOnError = false
+	}
+}
+
+// WithActivityOptions sets the activity options used for compensation activities. By default the activity options
+// of the context given to Compensate are used.
+func WithActivityOptions(options workflow.ActivityOptions) Option {
+	return func(s *Saga) {
+		s.activityOptions = &options
+	}
+}
+
+// WithRetryPolicy sets the retry policy of compensation activities
+func WithRetryPolicy(policy *cadence.RetryPolicy) Option {
+	return func(s *Saga) {
+		s.retryPolicy = policy
+	}
+}
+
+// New creates a saga
+func New(opts ...Option) *Saga {
+	s := &Saga{continueOnError: true}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// AddCompensation registers an activity (function or registered name) which undoes a completed step
+func (s *Saga) AddCompensation(activity interface{}, args ...interface{}) {
+	s.compensations = append(s.compensations, compensation{
+		name: activityName(activity),
+		fn: func(ctx workflow.Context) error {
+			return workflow.ExecuteActivity(ctx, activity, args...).Get(ctx, nil)
+		},
+	})
+}
+
+// AddCompensationFunc registers a function which undoes a completed step e.g. a child workflow or a few activities.
+// The context given to the function has the compensation activity options and retry policy.
+func (s *Saga) AddCompensationFunc(name string, fn func(ctx workflow.Context) error) {
+	s.compensations = append(s.compensations, compensation{name: name, fn: fn})
+}
+
+// Len returns the number of registered compensations
+func (s *Saga) Len() int {
+	return len(s.compensations)
+}
+
+// Compensate runs the compensations and returns the cause (the error which failed the workflow). If a compensation
+// fails, a *CompensationError is returned which has the cause and the failures.
+//
+// The compensations run in a disconnected context so they also run when the workflow is cancelled. Compensate runs
+// the compensations only once - later calls return the cause as is.
+func (s *Saga) Compensate(ctx workflow.Context, cause error) error {
+	if s.compensated || len(s.compensations) == 0 {
+		return cause
+	}
+	s.compensated = true
+
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	if s.activityOptions != nil {
+		ctx = workflow.WithActivityOptions(ctx, *s.activityOptions)
+	}
+	if s.retryPolicy != nil {
+		ctx = workflow.WithRetryPolicy(ctx, *s.retryPolicy)
+	}
+
+	var failures []Failure
+	if s.parallel {
+		failures = s.compensateParallel(ctx)
+	} else {
+		failures = s.compensateSequential(ctx)
+	}
+
+	if len(failures) == 0 {
+		return cause
+	}
+	workflow.GetLogger(ctx).Error(fmt.Sprintf("saga compensation failed: %d of %d compensations failed", len(failures), len(s.compensations)))
+	return &CompensationError{Cause: cause, Failures: failures}
+}
+
+// compensateSequential runs the compensations in reverse order
+func (s *Saga) compensateSequential(ctx workflow.Context) []Failure {
+	var failures []Failure
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		if err := s.compensations[i].fn(ctx); err != nil {
+			failures = append(failures, Failure{Step: i, Name: s.compensations[i].name, Err: err})
+			if !s.continueOnError {
+				break
+			}
+		}
+	}
+	return failures
+}
+
+// compensateParallel runs all compensations at the same time and waits for all of them
+func (s *Saga) compensateParallel(ctx workflow.Context) []Failure {
+	errs := make([]error, len(s.compensations))
+	wg := workflow.NewWaitGroup(ctx)
+	for i := range s.compensations {
+		wg.Add(1)
+		workflow.Go(ctx, func(ctx workflow.Context) {
+			defer wg.Done()
+			errs[i] = s.compensations[i].fn(ctx)
+		})
+	}
+	wg.Wait(ctx)
+
+	var failures []Failure
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i] != nil {
+			failures = append(failures, Failure{Step: i, Name: s.compensations[i].name, Err: errs[i]})
+		}
+	}
+	return failures
+}
+
+// Failure is a compensation which failed
+type Failure struct {
+	// Step is the index of the compensation (in the order they were added)
+	Step int
+	Name string
+	Err  error
+}
+
+// CompensationError is returned by Compensate when one or more compensations failed
+type CompensationError struct {
+	// Cause is the error which started the compensation
+	Cause    error
+	Failures []Failure
+}
+
+func (e *CompensationError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("compensation failed for %d step(s)", len(e.Failures)))
+	for _, f := range e.Failures {
+		sb.WriteString(fmt.Sprintf("; %s: %v", f.Name, f.Err))
+	}
+	if e.Cause != nil {
+		sb.WriteString(fmt.Sprintf(" (cause: %v)", e.Cause))
+	}
+	return sb.String()
+}
+
+// Unwrap returns the cause
+func (e *CompensationError) Unwrap() error {
+	return e.Cause
+}
+
+// ReasonCompensationFailed is the reason of the custom error returned by ToCustomError
+const ReasonCompensationFailed = "gox.saga.CompensationFailed"
+
+// FailureDetail is the serializable form of a Failure (see ToCustomError)
+type FailureDetail struct {
+	Step  int    `json:"step"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ToCustomError converts the error to a cadence custom error, so the caller of the workflow gets the failures as
+// details (a []FailureDetail) instead of a plain message
+func (e *CompensationError) ToCustomError() *cadence.CustomError {
+	details := make([]FailureDetail, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		details = append(details, FailureDetail{Step: f.Step, Name: f.Name, Error: f.Err.Error()})
+	}
+	return cadence.NewCustomError(ReasonCompensationFailed, details)
+}
+
+// activityName returns the name used for an activity in failures
+func activityName(activity interface{}) string {
+	if name, ok := activity.(string); ok {
+		return name
+	}
+	if v := reflect.ValueOf(activity); v.Kind() == reflect.Func {
+		return strings.TrimSuffix(runtime.FuncForPC(v.Pointer()).Name(), "-fm")
+	}
+	return fmt.Sprintf("%T", activity)
+}
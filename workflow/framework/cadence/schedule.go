@@ -0,0 +1,388 @@
+package cadence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/robfig/cron"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ScheduleWorkflowName is the cron workflow which starts the scheduled workflow on every fire
+	ScheduleWorkflowName = "gox.schedule.Launcher"
+
+	// ScheduleOverlapSkip skips a fire while the previous run is still running (default)
+	ScheduleOverlapSkip = "skip"
+
+	// ScheduleOverlapAllow starts a new run on every fire even if the previous run is still running
+	ScheduleOverlapAllow = "allow"
+
+	scheduleIDPrefix      = "gox-schedule:"
+	scheduleHashMemoKey   = "gox_schedule_hash"
+	schedulePausedReason  = "gox-schedule: paused"
+	scheduleUpdatedReason = "gox-schedule: updated"
+	scheduleRemovedReason = "gox-schedule: removed"
+	scheduleNextFireTimes = 5
+)
+
+// Schedule runs a workflow on a cron schedule. Schedules are declared in the worker group and reconciled when the
+// worker group starts: missing schedules are started, changed schedules are restarted and schedules removed from the
+// config are terminated. Paused schedules stay paused.
+//
+// Every schedule runs as a cadence cron workflow (ScheduleWorkflowName, workflow id "gox-schedule:<name>") on the task
+// list of the schedule, which starts the workflow as a child workflow. The child is abandoned if the schedule is paused
+// or updated, so a running workflow is never killed by a schedule change.
+type Schedule struct {
+	Name     string `json:"name" yaml:"name"`
+	Workflow string `json:"workflow" yaml:"workflow"`
+
+	// TaskList must be a task list of the worker group
+	TaskList string `json:"task_list" yaml:"task_list"`
+
+	// Cron is the cron expression in UTC e.g. "*/15 * * * *"
+	Cron string `json:"cron" yaml:"cron"`
+
+	// Input is given as the only argument to the workflow (no argument if empty)
+	Input interface{} `json:"input" yaml:"input"`
+
+	// OverlapPolicy is "skip" (default) or "allow"
+	OverlapPolicy string `json:"overlap_policy" yaml:"overlap_policy"`
+
+	// Jitter delays every run by a random time up to this value
+	Jitter time.Duration `json:"jitter" yaml:"jitter"`
+
+	// ExecutionTimeout is the execution timeout of the workflow (default 1h)
+	ExecutionTimeout time.Duration `json:"execution_timeout" yaml:"execution_timeout"`
+}
+
+// ScheduleInfo is the state of a schedule
+type ScheduleInfo struct {
+	Name          string      `json:"name"`
+	WorkerGroup   string      `json:"worker_group"`
+	Workflow      string      `json:"workflow"`
+	TaskList      string      `json:"task_list"`
+	Cron          string      `json:"cron"`
+	Running       bool        `json:"running"`
+	Paused        bool        `json:"paused"`
+	WorkflowID    string      `json:"workflow_id"`
+	RunID         string      `json:"run_id,omitempty"`
+	NextFireTimes []time.Time `json:"next_fire_times,omitempty"`
+}
+
+// Validate checks the schedule and sets the defaults. taskLists are the task lists of the worker group.
+func (s *Schedule) Validate(taskLists map[string]bool) error {
+	switch {
+	case len(s.Name) == 0:
+		return errors.New("schedule name is empty")
+	case len(s.Workflow) == 0:
+		return errors.New("workflow is empty for schedule = %s", s.Name)
+	case !taskLists[s.TaskList]:
+		return errors.New("task list %s of schedule %s is not a task list of the worker group", s.TaskList, s.Name)
+	case s.Jitter < 0:
+		return errors.New("jitter is negative for schedule = %s", s.Name)
+	}
+	if _, err := cron.ParseStandard(s.Cron); err != nil {
+		return errors.Wrap(err, "bad cron expression for schedule = %s", s.Name)
+	}
+
+	if len(s.OverlapPolicy) == 0 {
+		s.OverlapPolicy = ScheduleOverlapSkip
+	}
+	if s.OverlapPolicy != ScheduleOverlapSkip && s.OverlapPolicy != ScheduleOverlapAllow {
+		return errors.New("overlap_policy must be %s or %s for schedule = %s", ScheduleOverlapSkip, ScheduleOverlapAllow, s.Name)
+	}
+	if s.ExecutionTimeout == 0 {
+		s.ExecutionTimeout = time.Hour
+	}
+	return nil
+}
+
+// NextFireTimes returns the next n fire times after the given time (without jitter)
+func (s *Schedule) NextFireTimes(after time.Time, n int) ([]time.Time, error) {
+	schedule, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad cron expression for schedule = %s", s.Name)
+	}
+	times := make([]time.Time, 0, n)
+	t := after.UTC()
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+func (s *Schedule) workflowID() string {
+	return scheduleIDPrefix + s.Name
+}
+
+// hash is used to find if the running schedule has the same config
+func (s *Schedule) hash() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+var registerScheduleLauncherOnce sync.Once
+
+// registerScheduleLauncher registers the launcher workflow (once per process)
+func registerScheduleLauncher() {
+	registerScheduleLauncherOnce.Do(func() {
+		workflow.RegisterWithOptions(scheduleLauncherWorkflow, workflow.RegisterOptions{Name: ScheduleWorkflowName})
+	})
+}
+
+// scheduleLauncherWorkflow runs on every cron fire and starts the scheduled workflow
+func scheduleLauncherWorkflow(ctx workflow.Context, s Schedule) error {
+	if s.Jitter > 0 {
+		var jitter time.Duration
+		if err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+			return time.Duration(rand.Int63n(int64(s.Jitter)))
+		}).Get(&jitter); err != nil {
+			return err
+		}
+		if err := workflow.Sleep(ctx, jitter); err != nil {
+			return err
+		}
+	}
+
+	info := workflow.GetInfo(ctx)
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:                   fmt.Sprintf("%s:%d", info.WorkflowExecution.ID, workflow.Now(ctx).Unix()),
+		TaskList:                     s.TaskList,
+		ExecutionStartToCloseTimeout: s.ExecutionTimeout,
+		TaskStartToCloseTimeout:      10 * time.Second,
+		ParentClosePolicy:            client.ParentClosePolicyAbandon,
+	})
+
+	var args []interface{}
+	if s.Input != nil {
+		args = append(args, s.Input)
+	}
+	child := workflow.ExecuteChildWorkflow(ctx, s.Workflow, args...)
+	if s.OverlapPolicy == ScheduleOverlapAllow {
+		return child.GetChildWorkflowExecution().Get(ctx, nil)
+	}
+
+	// The next fire is skipped by cadence while this run is waiting for the child
+	return child.Get(ctx, nil)
+}
+
+// scheduleState is the state of the cron workflow of a schedule
+type scheduleState struct {
+	found   bool
+	running bool
+	paused  bool
+	runID   string
+	hash    string
+}
+
+// reconcileSchedules starts, restarts or terminates the cron workflows to match the schedules in the config. Errors
+// are logged - a bad schedule does not stop the workers.
+func (w *cadenceWorker) reconcileSchedules(ctx context.Context) {
+	configured := map[string]bool{}
+	for _, s := range w.workerGroup.Schedules {
+		configured[s.workflowID()] = true
+		if err := w.reconcileSchedule(ctx, s); err != nil {
+			w.tallyScope.Tagged(map[string]string{"schedule": s.Name}).Counter("gox_schedule_reconcile_failed").Inc(1)
+			w.slogger.Error("failed to reconcile schedule", slog.String("schedule", s.Name), slog.String("error", err.Error()))
+		}
+	}
+	if err := w.terminateRemovedSchedules(ctx, configured); err != nil {
+		w.slogger.Error("failed to terminate removed schedules", slog.String("error", err.Error()))
+	}
+}
+
+func (w *cadenceWorker) reconcileSchedule(ctx context.Context, s *Schedule) error {
+	state, err := w.scheduleState(ctx, s)
+	switch {
+	case err != nil:
+		return err
+	case state.paused:
+		w.slogger.Info("schedule is paused", slog.String("schedule", s.Name))
+		return nil
+	case state.running && state.hash == s.hash():
+		return nil
+	case state.running:
+		w.slogger.Info("schedule changed - restarting", slog.String("schedule", s.Name))
+		if err := w.cadenceClient.TerminateWorkflow(ctx, s.workflowID(), state.runID, scheduleUpdatedReason, nil); err != nil {
+			return errors.Wrap(err, "failed to terminate schedule to update it")
+		}
+	}
+	return w.startSchedule(ctx, s)
+}
+
+// startSchedule starts the cron workflow - already started is not an error (another instance started it)
+func (w *cadenceWorker) startSchedule(ctx context.Context, s *Schedule) error {
+	_, err := w.cadenceClient.StartWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                              s.workflowID(),
+		TaskList:                        s.TaskList,
+		ExecutionStartToCloseTimeout:    s.Jitter + s.ExecutionTimeout + time.Minute,
+		DecisionTaskStartToCloseTimeout: 10 * time.Second,
+		WorkflowIDReusePolicy:           client.WorkflowIDReusePolicyAllowDuplicate,
+		CronSchedule:                    s.Cron,
+		Memo:                            map[string]interface{}{scheduleHashMemoKey: s.hash()},
+	}, ScheduleWorkflowName, *s)
+
+	var alreadyStarted *shared.WorkflowExecutionAlreadyStartedError
+	if err != nil && !errors.As(err, &alreadyStarted) {
+		return errors.Wrap(err, "failed to start schedule = %s", s.Name)
+	}
+	w.slogger.Info("schedule started", slog.String("schedule", s.Name), slog.String("cron", s.Cron))
+	return nil
+}
+
+// scheduleState describes the cron workflow. A schedule is paused if its last run was terminated by PauseSchedule.
+func (w *cadenceWorker) scheduleState(ctx context.Context, s *Schedule) (*scheduleState, error) {
+	resp, err := w.cadenceClient.DescribeWorkflowExecution(ctx, s.workflowID(), "")
+	var notExists *shared.EntityNotExistsError
+	if err != nil && errors.As(err, &notExists) {
+		return &scheduleState{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to describe schedule = %s", s.Name)
+	}
+
+	info := resp.GetWorkflowExecutionInfo()
+	state := &scheduleState{found: true, running: info.CloseStatus == nil, runID: info.GetExecution().GetRunId()}
+	if data, ok := info.GetMemo().GetFields()[scheduleHashMemoKey]; ok {
+		_ = json.Unmarshal(data, &state.hash)
+	}
+
+	if info.GetCloseStatus() == shared.WorkflowExecutionCloseStatusTerminated {
+		iter := w.cadenceClient.GetWorkflowHistory(ctx, s.workflowID(), state.runID, false, shared.HistoryEventFilterTypeCloseEvent)
+		for iter.HasNext() {
+			event, err := iter.Next()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read close event of schedule = %s", s.Name)
+			}
+			if attributes := event.WorkflowExecutionTerminatedEventAttributes; attributes != nil {
+				state.paused = attributes.GetReason() == schedulePausedReason
+			}
+		}
+	}
+	return state, nil
+}
+
+// terminateRemovedSchedules terminates the schedules running on the task lists of this worker group which are not in
+// the config any more
+func (w *cadenceWorker) terminateRemovedSchedules(ctx context.Context, configured map[string]bool) error {
+	taskLists := map[string]bool{}
+	for _, tl := range w.workerGroup.Workers {
+		taskLists[tl.TaskList] = true
+	}
+
+	workflowType := ScheduleWorkflowName
+	earliest, latest := int64(0), time.Now().UnixNano()
+	var token []byte
+	for {
+		resp, err := w.cadenceClient.ListOpenWorkflow(ctx, &shared.ListOpenWorkflowExecutionsRequest{
+			Domain:          &w.workerGroup.Domain,
+			StartTimeFilter: &shared.StartTimeFilter{EarliestTime: &earliest, LatestTime: &latest},
+			TypeFilter:      &shared.WorkflowTypeFilter{Name: &workflowType},
+			NextPageToken:   token,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list open schedules")
+		}
+
+		for _, e := range resp.GetExecutions() {
+			id := e.GetExecution().GetWorkflowId()
+			if !strings.HasPrefix(id, scheduleIDPrefix) || configured[id] || !taskLists[e.GetTaskList()] {
+				continue
+			}
+			if err := w.cadenceClient.TerminateWorkflow(ctx, id, e.GetExecution().GetRunId(), scheduleRemovedReason, nil); err != nil {
+				return errors.Wrap(err, "failed to terminate removed schedule = %s", id)
+			}
+			w.slogger.Info("schedule removed from config - terminated", slog.String("workflowId", id))
+		}
+
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			return nil
+		}
+	}
+}
+
+// findSchedule returns the worker group and the config of a schedule
+func (wrapper *cadenceWrapperImpl) findSchedule(name string) (*cadenceWorker, *Schedule, error) {
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		for _, s := range cadenceWorkerObj.workerGroup.Schedules {
+			if s.Name == name {
+				return cadenceWorkerObj, s, nil
+			}
+		}
+	}
+	return nil, nil, errors.New("schedule not found in application config: %s", name)
+}
+
+func (wrapper *cadenceWrapperImpl) ListSchedules(ctx context.Context) ([]ScheduleInfo, error) {
+	result := make([]ScheduleInfo, 0)
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		for _, s := range cadenceWorkerObj.workerGroup.Schedules {
+			state, err := cadenceWorkerObj.scheduleState(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			info := ScheduleInfo{
+				Name:        s.Name,
+				WorkerGroup: cadenceWorkerObj.workerGroup.Name,
+				Workflow:    s.Workflow,
+				TaskList:    s.TaskList,
+				Cron:        s.Cron,
+				Running:     state.running,
+				Paused:      state.paused,
+				WorkflowID:  s.workflowID(),
+				RunID:       state.runID,
+			}
+			if state.running {
+				if info.NextFireTimes, err = s.NextFireTimes(time.Now(), scheduleNextFireTimes); err != nil {
+					return nil, err
+				}
+			}
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+func (wrapper *cadenceWrapperImpl) PauseSchedule(ctx context.Context, name string) error {
+	cadenceWorkerObj, s, err := wrapper.findSchedule(name)
+	if err != nil {
+		return err
+	}
+	state, err := cadenceWorkerObj.scheduleState(ctx, s)
+	switch {
+	case err != nil:
+		return err
+	case state.paused:
+		return nil
+	case !state.running:
+		return errors.New("schedule is not running: %s", name)
+	}
+	return cadenceWorkerObj.cadenceClient.TerminateWorkflow(ctx, s.workflowID(), state.runID, schedulePausedReason, nil)
+}
+
+func (wrapper *cadenceWrapperImpl) ResumeSchedule(ctx context.Context, name string) error {
+	cadenceWorkerObj, s, err := wrapper.findSchedule(name)
+	if err != nil {
+		return err
+	}
+	state, err := cadenceWorkerObj.scheduleState(ctx, s)
+	switch {
+	case err != nil:
+		return err
+	case state.running:
+		return nil
+	}
+	return cadenceWorkerObj.startSchedule(ctx, s)
+}
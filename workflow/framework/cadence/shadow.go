@@ -0,0 +1,259 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ShadowModeAlongside runs the shadower along with the normal pollers (default)
+	ShadowModeAlongside = "alongside"
+
+	// ShadowModeOnly runs only the shadower - the normal pollers are not started for the task list
+	ShadowModeOnly = "only"
+
+	defaultShadowInterval = time.Minute
+	defaultShadowPageSize = 100
+)
+
+// ShadowConfig enables the shadow mode for a worker (or all workers of a worker group). In shadow mode the workflows
+// of the domain are sampled using a visibility query and replayed against the workflow code of this binary. The
+// activities are not executed. Non-deterministic workflows are reported with logs and metrics.
+//
+// e.g.
+//
+//	shadow:
+//	  enabled: true
+//	  mode: only
+//	  query: "WorkflowType = 'OrderWorkflow' AND CloseTime = missing"
+//	  sampling_rate: 0.1
+//	  interval: 5m
+type ShadowConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Mode is "alongside" (default) or "only"
+	Mode string `json:"mode" yaml:"mode"`
+
+	// Query is the visibility query to select the workflows to replay (default all workflows of the domain). It is
+	// limited to the task list of the worker with "AND TaskList = '<task list>'".
+	Query string `json:"query" yaml:"query"`
+
+	// SamplingRate is the fraction of the workflows matching the query to replay (0, 1] - default 1
+	SamplingRate float64 `json:"sampling_rate" yaml:"sampling_rate"`
+
+	// Interval is the wait time between two scans of the workflows (default 1m)
+	Interval time.Duration `json:"interval" yaml:"interval"`
+
+	// PageSize is the number of workflows read in a single visibility call (default 100)
+	PageSize int32 `json:"page_size" yaml:"page_size"`
+}
+
+// Validate validates the shadow config
+func (s *ShadowConfig) Validate() error {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	if len(s.Mode) > 0 && s.Mode != ShadowModeAlongside && s.Mode != ShadowModeOnly {
+		return errors.New("shadow mode must be %s or %s - found %s", ShadowModeAlongside, ShadowModeOnly, s.Mode)
+	}
+	if s.SamplingRate < 0 || s.SamplingRate > 1 {
+		return errors.New("shadow sampling_rate must be in range (0, 1] - found %f", s.SamplingRate)
+	}
+	if s.Interval < 0 {
+		return errors.New("shadow interval must not be negative - found %s", s.Interval)
+	}
+	if s.PageSize < 0 {
+		return errors.New("shadow page_size must not be negative - found %d", s.PageSize)
+	}
+	return nil
+}
+
+// shadowOnly returns true if the normal pollers must not be started
+func (s *ShadowConfig) shadowOnly() bool {
+	return s != nil && s.Enabled && s.Mode == ShadowModeOnly
+}
+
+// shadowConfig returns the shadow config of the worker - worker level config overrides the worker group config
+func (s *Worker) shadowConfig(wg *WorkerGroup) *ShadowConfig {
+	if s.Shadow != nil {
+		return s.Shadow
+	}
+	return wg.Shadow
+}
+
+// shadower samples the workflows of a domain and replays them against the registered workflows
+type shadower struct {
+	domain   string
+	taskList string
+	config   ShadowConfig
+
+	client   client.Client
+	service  workflowserviceclient.Interface
+	replayer worker.WorkflowReplayer
+	logger   *zap.Logger
+	slogger  *slog.Logger
+	scope    tally.Scope
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newShadower(w *cadenceWorker, taskList string, config ShadowConfig, wi WorkerInterceptors) *shadower {
+	if config.SamplingRate == 0 {
+		config.SamplingRate = 1
+	}
+	if config.Interval == 0 {
+		config.Interval = defaultShadowInterval
+	}
+	if config.PageSize == 0 {
+		config.PageSize = defaultShadowPageSize
+	}
+	return &shadower{
+		domain:   w.workerGroup.Domain,
+		taskList: taskList,
+		config:   config,
+		client:   w.cadenceClient,
+		service:  w.cadenceServiceClient,
+		replayer: worker.NewWorkflowReplayerWithOptions(worker.ReplayOptions{WorkflowInterceptorChainFactories: wi.Workflow}),
+		logger:   w.logger(taskList).Named("shadow"),
+		slogger:  w.slogger.With(slog.String("taskList", taskList), slog.String("component", "shadow")),
+		scope:    w.tallyScope.Tagged(map[string]string{"domain": w.workerGroup.Domain, "task_list": taskList}),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start runs the shadower in background until Stop is called
+func (s *shadower) Start() {
+	s.slogger.Info("starting cadence shadow worker", slog.String("query", s.config.Query), slog.Float64("samplingRate", s.config.SamplingRate))
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			s.scan()
+			select {
+			case <-s.stopCh:
+				return
+			case <-time.After(s.config.Interval):
+			}
+		}
+	}()
+}
+
+// Stop stops the shadower and waits for the running replay to finish
+func (s *shadower) Stop() {
+	close(s.stopCh)
+	s.wg.Wait()
+}
+
+// query is the visibility query of the shadower limited to its task list - each task list of the worker group has its
+// own shadower, so without it the workflows would be replayed once for every task list
+func (s *shadower) query() string {
+	taskList := "TaskList = '" + strings.ReplaceAll(s.taskList, "'", "\\'") + "'"
+	query := strings.TrimSpace(s.config.Query)
+	if len(query) == 0 {
+		return taskList
+	}
+
+	// ORDER BY must stay at the end of the query
+	orderBy := ""
+	if i := strings.Index(strings.ToUpper(query), "ORDER BY"); i >= 0 {
+		query, orderBy = strings.TrimSpace(query[:i]), " "+query[i:]
+	}
+	if len(query) == 0 {
+		return taskList + orderBy
+	}
+	return "(" + query + ") AND " + taskList + orderBy
+}
+
+// scan reads all the workflows matching the query and replays the sampled ones
+func (s *shadower) scan() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	query := s.query()
+	var nextPageToken []byte
+	for {
+		response, err := s.client.ScanWorkflow(ctx, &shared.ListWorkflowExecutionsRequest{
+			Domain:        &s.domain,
+			PageSize:      &s.config.PageSize,
+			Query:         &query,
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				s.scope.Counter("gox_shadow_scan_failed").Inc(1)
+				s.slogger.Error("failed to scan workflows for shadowing", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		for _, info := range response.Executions {
+			if ctx.Err() != nil {
+				return
+			}
+			if rand.Float64() >= s.config.SamplingRate {
+				continue
+			}
+			s.replay(ctx, info)
+		}
+
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			return
+		}
+	}
+}
+
+// replay replays a single workflow execution and reports the result
+func (s *shadower) replay(ctx context.Context, info *shared.WorkflowExecutionInfo) {
+	workflowType := info.GetType().GetName()
+	execution := workflow.Execution{ID: info.GetExecution().GetWorkflowId(), RunID: info.GetExecution().GetRunId()}
+	scope := s.scope.Tagged(map[string]string{"workflow_type": workflowType})
+
+	err := s.replayer.ReplayWorkflowExecution(ctx, s.service, s.logger, s.domain, execution)
+	switch {
+	case err == nil:
+		scope.Counter("gox_shadow_replayed").Inc(1)
+	case strings.Contains(err.Error(), "unable to find workflow type"):
+		scope.Counter("gox_shadow_skipped").Inc(1)
+		s.slogger.Debug("workflow type not registered - skip shadowing", slog.String("workflowType", workflowType))
+	case strings.Contains(err.Error(), "nondeterministic"):
+		scope.Counter("gox_shadow_replayed").Inc(1)
+		scope.Counter("gox_shadow_nondeterministic").Inc(1)
+		s.slogger.Error("non-deterministic workflow found by shadow worker",
+			slog.String("workflowType", workflowType),
+			slog.String("workflowID", execution.ID),
+			slog.String("runID", execution.RunID),
+			slog.String("error", err.Error()),
+		)
+	case ctx.Err() != nil:
+		// stopped
+	default:
+		scope.Counter("gox_shadow_failed").Inc(1)
+		s.slogger.Warn("failed to replay workflow in shadow worker",
+			slog.String("workflowType", workflowType),
+			slog.String("workflowID", execution.ID),
+			slog.String("runID", execution.RunID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,240 @@
+package cadence
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+)
+
+// ExecutionRef identifies a workflow execution along with the task list used to route the calls to the right
+// worker group
+type ExecutionRef struct {
+	TaskList   string
+	WorkflowID string
+	RunID      string
+}
+
+// context returns a context with the task list set - this is required by the Api for Query, Signal etc
+func (r ExecutionRef) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, TaskListForAction, r.TaskList)
+}
+
+// WorkflowDef is a typed handle to a workflow. It makes sure that the input and output types used by the callers
+// are the same as the ones used by the workflow function.
+//
+// e.g.
+//
+//	var orderWorkflow = cadence.NewWorkflowDef(OrderWorkflow) // func OrderWorkflow(ctx workflow.Context, in Order) (*Receipt, error)
+//	orderWorkflow.Register()
+//	receipt, err := orderWorkflow.Execute(ctx, api, options, Order{...})
+type WorkflowDef[In, Out any] struct {
+	name string
+	fn   func(ctx workflow.Context, in In) (Out, error)
+}
+
+// NewWorkflowDef creates a typed handle for a workflow function
+func NewWorkflowDef[In, Out any](fn func(ctx workflow.Context, in In) (Out, error)) WorkflowDef[In, Out] {
+	return WorkflowDef[In, Out]{name: functionName(fn), fn: fn}
+}
+
+// NewWorkflowDefWithName creates a typed handle for a workflow registered with the given name. Use it when the
+// workflow function is not linked in this binary (fn is nil and Register must not be called), or it is registered
+// with a custom name.
+func NewWorkflowDefWithName[In, Out any](name string, fn func(ctx workflow.Context, in In) (Out, error)) WorkflowDef[In, Out] {
+	return WorkflowDef[In, Out]{name: name, fn: fn}
+}
+
+// Name returns the workflow type name
+func (d WorkflowDef[In, Out]) Name() string {
+	return d.name
+}
+
+// Register registers the workflow function with cadence using the name of this handle
+func (d WorkflowDef[In, Out]) Register() {
+	workflow.RegisterWithOptions(d.fn, workflow.RegisterOptions{Name: d.name})
+}
+
+// Start starts the workflow and returns a handle to get the result
+func (d WorkflowDef[In, Out]) Start(ctx context.Context, api Api, options client.StartWorkflowOptions, in In) (*WorkflowHandle[Out], error) {
+	execution, err := api.StartWorkflow(ctx, options, d.name, in)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkflowHandle[Out]{
+		ExecutionRef: ExecutionRef{TaskList: options.TaskList, WorkflowID: execution.ID, RunID: execution.RunID},
+		api:          api,
+	}, nil
+}
+
+// Execute starts the workflow and waits for the result
+func (d WorkflowDef[In, Out]) Execute(ctx context.Context, api Api, options client.StartWorkflowOptions, in In) (Out, error) {
+	var out Out
+	run, err := api.ExecuteWorkflow(ctx, options, d.name, in)
+	if err != nil {
+		return out, err
+	}
+	err = run.Get(ctx, &out)
+	return out, err
+}
+
+// ExecuteChild runs this workflow as a child workflow and waits for the result. It must be called from a workflow.
+func (d WorkflowDef[In, Out]) ExecuteChild(ctx workflow.Context, in In) (Out, error) {
+	var out Out
+	err := workflow.ExecuteChildWorkflow(ctx, d.name, in).Get(ctx, &out)
+	return out, err
+}
+
+// WorkflowHandle is a typed handle to a started workflow execution
+type WorkflowHandle[Out any] struct {
+	ExecutionRef
+	api Api
+}
+
+// NewWorkflowHandle creates a typed handle for an existing workflow execution
+func NewWorkflowHandle[Out any](api Api, ref ExecutionRef) *WorkflowHandle[Out] {
+	return &WorkflowHandle[Out]{ExecutionRef: ref, api: api}
+}
+
+// Get waits for the workflow to complete and returns the result
+func (h *WorkflowHandle[Out]) Get(ctx context.Context) (Out, error) {
+	var out Out
+	run, err := h.api.GetWorkflow(h.ExecutionRef.context(ctx), h.WorkflowID, h.RunID)
+	if err != nil {
+		return out, err
+	}
+	err = run.Get(ctx, &out)
+	return out, err
+}
+
+// QueryDef is a typed query. The same definition is used inside the workflow to set the handler and by the
+// callers to query the workflow.
+type QueryDef[T any] struct {
+	name string
+}
+
+// NewQueryDef creates a typed query with the given query type name
+func NewQueryDef[T any](name string) QueryDef[T] {
+	return QueryDef[T]{name: name}
+}
+
+// Name returns the query type name
+func (q QueryDef[T]) Name() string {
+	return q.name
+}
+
+// SetHandler sets the query handler in the workflow
+func (q QueryDef[T]) SetHandler(ctx workflow.Context, handler func() (T, error)) error {
+	return workflow.SetQueryHandler(ctx, q.name, handler)
+}
+
+// Query queries the workflow execution and returns the typed result
+func (q QueryDef[T]) Query(ctx context.Context, api Api, ref ExecutionRef) (T, error) {
+	var out T
+	value, err := api.QueryWorkflow(ref.context(ctx), ref.WorkflowID, ref.RunID, q.name)
+	if err != nil {
+		return out, err
+	}
+	err = value.Get(&out)
+	return out, err
+}
+
+// SignalDef is a typed signal. The same definition is used inside the workflow to receive the signal and by the
+// callers to send the signal.
+type SignalDef[T any] struct {
+	name string
+}
+
+// NewSignalDef creates a typed signal with the given signal name
+func NewSignalDef[T any](name string) SignalDef[T] {
+	return SignalDef[T]{name: name}
+}
+
+// Name returns the signal name
+func (s SignalDef[T]) Name() string {
+	return s.name
+}
+
+// Send sends the signal to the workflow execution
+func (s SignalDef[T]) Send(ctx context.Context, api Api, ref ExecutionRef, arg T) error {
+	return api.SignalWorkflow(ref.context(ctx), ref.WorkflowID, ref.RunID, s.name, arg)
+}
+
+// SendExternal sends the signal to another workflow execution. It must be called from a workflow.
+func (s SignalDef[T]) SendExternal(ctx workflow.Context, workflowID string, runID string, arg T) workflow.Future {
+	return workflow.SignalExternalWorkflow(ctx, workflowID, runID, s.name, arg)
+}
+
+// Receive blocks until the signal is received. It must be called from a workflow.
+func (s SignalDef[T]) Receive(ctx workflow.Context) T {
+	var out T
+	workflow.GetSignalChannel(ctx, s.name).Receive(ctx, &out)
+	return out
+}
+
+// ReceiveAsync returns the signal if it is already received. It must be called from a workflow.
+func (s SignalDef[T]) ReceiveAsync(ctx workflow.Context) (T, bool) {
+	var out T
+	ok := workflow.GetSignalChannel(ctx, s.name).ReceiveAsync(&out)
+	return out, ok
+}
+
+// Channel returns the signal channel, to be used with workflow.Selector
+func (s SignalDef[T]) Channel(ctx workflow.Context) workflow.Channel {
+	return workflow.GetSignalChannel(ctx, s.name)
+}
+
+// ActivityDef is a typed handle to an activity. It makes sure that the workflow passes the input type expected by
+// the activity and reads the result with the right type.
+type ActivityDef[In, Out any] struct {
+	name string
+	fn   func(ctx context.Context, in In) (Out, error)
+}
+
+// NewActivityDef creates a typed handle for an activity function
+func NewActivityDef[In, Out any](fn func(ctx context.Context, in In) (Out, error)) ActivityDef[In, Out] {
+	return ActivityDef[In, Out]{name: functionName(fn), fn: fn}
+}
+
+// NewActivityDefWithName creates a typed handle for an activity registered with the given name. fn can be nil if the
+// activity is not registered in this binary - Register and ExecuteLocal need the function.
+func NewActivityDefWithName[In, Out any](name string, fn func(ctx context.Context, in In) (Out, error)) ActivityDef[In, Out] {
+	return ActivityDef[In, Out]{name: name, fn: fn}
+}
+
+// Name returns the activity type name
+func (d ActivityDef[In, Out]) Name() string {
+	return d.name
+}
+
+// Register registers the activity with cadence (with interceptors - see RegisterActivity)
+func (d ActivityDef[In, Out]) Register() {
+	RegisterActivityWithOptions(d.fn, activity.RegisterOptions{Name: d.name})
+}
+
+// Execute runs the activity and waits for the result. It must be called from a workflow.
+func (d ActivityDef[In, Out]) Execute(ctx workflow.Context, in In) (Out, error) {
+	return d.ExecuteAsync(ctx, in).Get(ctx)
+}
+
+// ExecuteAsync runs the activity and returns a typed future. It must be called from a workflow.
+func (d ActivityDef[In, Out]) ExecuteAsync(ctx workflow.Context, in In) Future[Out] {
+	return Future[Out]{Future: workflow.ExecuteActivity(ctx, d.name, in)}
+}
+
+// ExecuteLocal runs the activity as a local activity and waits for the result. It must be called from a workflow.
+func (d ActivityDef[In, Out]) ExecuteLocal(ctx workflow.Context, in In) (Out, error) {
+	return Future[Out]{Future: workflow.ExecuteLocalActivity(ctx, d.fn, in)}.Get(ctx)
+}
+
+// Future is a typed workflow.Future
+type Future[T any] struct {
+	workflow.Future
+}
+
+// Get blocks until the future is ready and returns the typed result
+func (f Future[T]) Get(ctx workflow.Context) (T, error) {
+	var out T
+	err := f.Future.Get(ctx, &out)
+	return out, err
+}
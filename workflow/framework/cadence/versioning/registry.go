@@ -0,0 +1,132 @@
+// Package versioning wraps workflow.GetVersion with named changes declared in a central registry.
+//
+// Every change to workflow code which breaks determinism is declared once with the oldest version the code still
+// supports and the current version. The workflow code asks the registry for the version instead of repeating the
+// min/max values at every call site:
+//
+//	var registry = versioning.NewRegistry()
+//
+//	func init() {
+//		registry.MustDeclare(versioning.Change{
+//			ID:            "order-add-fraud-check",
+//			Description:   "fraud check before payment",
+//			MinSupported:  workflow.DefaultVersion,
+//			Max:           1,
+//			WorkflowTypes: []string{"main.OrderWorkflow"},
+//		})
+//	}
+//
+//	// in OrderWorkflow
+//	if registry.GetVersion(ctx, "order-add-fraud-check") >= 1 {
+//		err = workflow.ExecuteActivity(ctx, FraudCheck, order).Get(ctx, nil)
+//	}
+//
+// Every branch taken is counted in the gox_version_branch metric (tagged with change_id and version). The Reporter
+// finds the versions open workflows still depend on, so old branches can be deleted (and MinSupported raised) safely.
+package versioning
+
+import (
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/cadence/workflow"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Change is a change in workflow code guarded by workflow.GetVersion
+type Change struct {
+	// ID is the change id given to workflow.GetVersion
+	ID string `json:"id"`
+
+	// Description tells what the change is
+	Description string `json:"description,omitempty"`
+
+	// MinSupported is the oldest version the workflow code still supports (workflow.DefaultVersion until the
+	// original code branch is deleted)
+	MinSupported workflow.Version `json:"min_supported"`
+
+	// Max is the current version - new workflows take this branch
+	Max workflow.Version `json:"max"`
+
+	// WorkflowTypes are the workflows which run this change. Open workflows of these types which have not recorded
+	// a version for the change may still take the workflow.DefaultVersion branch - they are reported as using it.
+	WorkflowTypes []string `json:"workflow_types,omitempty"`
+}
+
+// Registry holds the declared changes
+type Registry struct {
+	mu      sync.RWMutex
+	changes map[string]Change
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{changes: map[string]Change{}}
+}
+
+// Declare adds changes to the registry
+func (r *Registry) Declare(changes ...Change) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range changes {
+		if len(c.ID) == 0 {
+			return errors.New("change id is required")
+		}
+		if c.MinSupported > c.Max {
+			return errors.New("change %s has min supported version %d greater than max version %d", c.ID, c.MinSupported, c.Max)
+		}
+		if _, ok := r.changes[c.ID]; ok {
+			return errors.New("change %s is already declared", c.ID)
+		}
+		r.changes[c.ID] = c
+	}
+	return nil
+}
+
+// MustDeclare adds changes to the registry and panics if a change is not valid
+func (r *Registry) MustDeclare(changes ...Change) {
+	if err := r.Declare(changes...); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns a declared change
+func (r *Registry) Get(id string) (Change, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.changes[id]
+	return c, ok
+}
+
+// Changes returns the declared changes sorted by id
+func (r *Registry) Changes() []Change {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	changes := make([]Change, 0, len(r.changes))
+	for _, c := range r.changes {
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes
+}
+
+// GetVersion calls workflow.GetVersion with the supported versions of the change and counts the branch taken in
+// the gox_version_branch metric. It panics if the change is not declared - like workflow.GetVersion does for a
+// version which is not supported, this fails the decision task until the code is fixed.
+func (r *Registry) GetVersion(ctx workflow.Context, changeID string) workflow.Version {
+	c, ok := r.Get(changeID)
+	if !ok {
+		panic("versioning: change " + changeID + " is not declared in the registry")
+	}
+	version := workflow.GetVersion(ctx, c.ID, c.MinSupported, c.Max)
+	workflow.GetMetricsScope(ctx).Tagged(map[string]string{
+		"change_id": c.ID,
+		"version":   strconv.Itoa(int(version)),
+	}).Counter("gox_version_branch").Inc(1)
+	return version
+}
+
+// AtLeast returns true if the workflow takes the branch of the given version (or a newer one) of the change
+func (r *Registry) AtLeast(ctx workflow.Context, changeID string, version workflow.Version) bool {
+	return r.GetVersion(ctx, changeID) >= version
+}
@@ -0,0 +1,337 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/encoded"
+	"go.uber.org/cadence/workflow"
+	"sort"
+)
+
+const (
+	// versionMarkerName is the marker recorded by workflow.GetVersion
+	versionMarkerName = "Version"
+
+	// changeVersionSearchAttribute is the search attribute upserted by workflow.GetVersion ("<change id>-<version>")
+	changeVersionSearchAttribute = "CadenceChangeVersion"
+)
+
+// WorkflowRef identifies a workflow run
+type WorkflowRef struct {
+	TaskList     string `json:"task_list"`
+	WorkflowType string `json:"workflow_type,omitempty"`
+	WorkflowID   string `json:"workflow_id"`
+	RunID        string `json:"run_id"`
+}
+
+// VersionUsage is the number of open workflows which depend on a version of a change
+type VersionUsage struct {
+	Version       workflow.Version `json:"version"`
+	OpenWorkflows int              `json:"open_workflows"`
+	Examples      []WorkflowRef    `json:"examples,omitempty"`
+}
+
+// ChangeReport tells which versions of a change are used by open workflows
+type ChangeReport struct {
+	ChangeID string `json:"change_id"`
+
+	// Declared is false for a change found in the workflows but not declared in the registry
+	Declared     bool             `json:"declared"`
+	MinSupported workflow.Version `json:"min_supported"`
+	Max          workflow.Version `json:"max"`
+
+	// Versions are sorted by version
+	Versions []VersionUsage `json:"versions"`
+}
+
+// InUse returns true if an open workflow depends on the version
+func (c ChangeReport) InUse(version workflow.Version) bool {
+	for _, v := range c.Versions {
+		if v.Version == version && v.OpenWorkflows > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SafeMinSupported is the oldest version still used by an open workflow (Max if none). Code branches for older
+// versions can be deleted and MinSupported raised to it.
+func (c ChangeReport) SafeMinSupported() workflow.Version {
+	for _, v := range c.Versions {
+		if v.OpenWorkflows > 0 {
+			return v.Version
+		}
+	}
+	return c.Max
+}
+
+// Report is the result of Reporter.Report
+type Report struct {
+	Changes []ChangeReport `json:"changes"`
+
+	// ScannedWorkflows is the number of open workflows whose history was read
+	ScannedWorkflows int `json:"scanned_workflows"`
+
+	// Truncated is true if the limit was hit in a worker group - the report may miss some workflows
+	Truncated bool `json:"truncated"`
+}
+
+// Reporter finds the versions of the changes which open workflows still depend on
+type Reporter struct {
+	api           cadence.Api
+	taskLists     []string
+	registry      *Registry
+	query         string
+	limit         int
+	examples      int
+	useVisibility bool
+}
+
+// ReporterOption configures the Reporter
+type ReporterOption func(r *Reporter)
+
+// WithRegistry reports the changes of the registry - without it only the changes found in the workflows are reported
+func WithRegistry(registry *Registry) ReporterOption {
+	return func(r *Reporter) {
+		r.registry = registry
+	}
+}
+
+// WithQuery adds a visibility query to select the open workflows to check e.g. "WorkflowType = 'main.OrderWorkflow'"
+func WithQuery(query string) ReporterOption {
+	return func(r *Reporter) {
+		r.query = query
+	}
+}
+
+// WithLimit sets the max open workflows checked per worker group (default 1000, 0 for all)
+func WithLimit(limit int) ReporterOption {
+	return func(r *Reporter) {
+		r.limit = limit
+	}
+}
+
+// WithExamples sets the number of example workflows kept per version (default 5)
+func WithExamples(examples int) ReporterOption {
+	return func(r *Reporter) {
+		r.examples = examples
+	}
+}
+
+// WithSearchAttributes counts the workflows using the CadenceChangeVersion search attribute instead of reading the
+// history of every open workflow. It is much faster but needs advanced visibility and the registry, and cannot see
+// workflows which depend on workflow.DefaultVersion.
+func WithSearchAttributes() ReporterOption {
+	return func(r *Reporter) {
+		r.useVisibility = true
+	}
+}
+
+// NewReporter creates a reporter for the worker groups of the task lists (one task list per worker group is enough)
+func NewReporter(api cadence.Api, taskLists []string, opts ...ReporterOption) *Reporter {
+	r := &Reporter{api: api, taskLists: taskLists, limit: 1000, examples: 5}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Report checks the open workflows and returns the versions of the changes they depend on
+func (r *Reporter) Report(ctx context.Context) (*Report, error) {
+	if r.useVisibility && r.registry == nil {
+		return nil, errors.New("search attribute report needs the registry - use WithRegistry")
+	}
+
+	c := newCollector(r)
+	for _, tl := range r.taskLists {
+		tlCtx := context.WithValue(ctx, cadence.TaskListForAction, tl)
+		var err error
+		if r.useVisibility {
+			err = r.countBySearchAttribute(tlCtx, tl, c)
+		} else {
+			err = r.scanHistories(tlCtx, tl, c)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.report(), nil
+}
+
+// scanHistories reads the version markers from the history of every open workflow
+func (r *Reporter) scanHistories(ctx context.Context, taskList string, c *collector) error {
+	return r.list(ctx, taskList, r.openQuery(), c, func(info *shared.WorkflowExecutionInfo) error {
+		ref := WorkflowRef{
+			TaskList:     taskList,
+			WorkflowType: info.GetType().GetName(),
+			WorkflowID:   info.GetExecution().GetWorkflowId(),
+			RunID:        info.GetExecution().GetRunId(),
+		}
+		history, err := r.api.GetWorkflowHistory(ctx, ref.WorkflowID, ref.RunID)
+		if err != nil {
+			return errors.Wrap(err, "failed to read history of workflow %s", ref.WorkflowID)
+		}
+		versions, err := versionMarkers(history)
+		if err != nil {
+			return errors.Wrap(err, "failed to read version markers of workflow %s", ref.WorkflowID)
+		}
+
+		c.scanned++
+		for changeID, version := range versions {
+			c.add(changeID, version, ref)
+		}
+
+		// Workflows of the change which did not record a version may still take the default branch
+		if r.registry != nil {
+			for _, change := range r.registry.Changes() {
+				if _, ok := versions[change.ID]; !ok && contains(change.WorkflowTypes, ref.WorkflowType) {
+					c.add(change.ID, workflow.DefaultVersion, ref)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// countBySearchAttribute counts the open workflows with each version of the declared changes
+func (r *Reporter) countBySearchAttribute(ctx context.Context, taskList string, c *collector) error {
+	for _, change := range r.registry.Changes() {
+		for version := max(change.MinSupported, 0); version <= change.Max; version++ {
+			query := fmt.Sprintf("%s AND %s = '%s-%d'", r.openQuery(), changeVersionSearchAttribute, change.ID, version)
+			err := r.list(ctx, taskList, query, c, func(info *shared.WorkflowExecutionInfo) error {
+				c.add(change.ID, version, WorkflowRef{
+					TaskList:     taskList,
+					WorkflowType: info.GetType().GetName(),
+					WorkflowID:   info.GetExecution().GetWorkflowId(),
+					RunID:        info.GetExecution().GetRunId(),
+				})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Reporter) openQuery() string {
+	if len(r.query) > 0 {
+		return "CloseTime = missing AND (" + r.query + ")"
+	}
+	return "CloseTime = missing"
+}
+
+// list calls fn for the workflows matching the query (at most limit if limit > 0)
+func (r *Reporter) list(ctx context.Context, taskList string, query string, c *collector, fn func(info *shared.WorkflowExecutionInfo) error) error {
+	count := 0
+	var token []byte
+	for {
+		pageSize := int32(100)
+		resp, err := r.api.ListWorkflow(ctx, &shared.ListWorkflowExecutionsRequest{Query: &query, PageSize: &pageSize, NextPageToken: token})
+		if err != nil {
+			return errors.Wrap(err, "failed to list workflows for task list %s", taskList)
+		}
+		for _, info := range resp.GetExecutions() {
+			if r.limit > 0 && count >= r.limit {
+				c.truncated = true
+				return nil
+			}
+			if err := fn(info); err != nil {
+				return err
+			}
+			count++
+		}
+		token = resp.GetNextPageToken()
+		if len(token) == 0 {
+			return nil
+		}
+	}
+}
+
+// versionMarkers returns the versions recorded by workflow.GetVersion in the history
+func versionMarkers(history *shared.History) (map[string]workflow.Version, error) {
+	versions := map[string]workflow.Version{}
+	for _, event := range history.GetEvents() {
+		if event.GetEventType() != shared.EventTypeMarkerRecorded || event.MarkerRecordedEventAttributes.GetMarkerName() != versionMarkerName {
+			continue
+		}
+		var changeID string
+		var version workflow.Version
+		if err := encoded.GetDefaultDataConverter().FromData(event.MarkerRecordedEventAttributes.GetDetails(), &changeID, &version); err != nil {
+			return nil, err
+		}
+		versions[changeID] = version
+	}
+	return versions, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// collector builds the report
+type collector struct {
+	reporter  *Reporter
+	scanned   int
+	truncated bool
+	usage     map[string]map[workflow.Version]*VersionUsage
+}
+
+func newCollector(r *Reporter) *collector {
+	return &collector{reporter: r, usage: map[string]map[workflow.Version]*VersionUsage{}}
+}
+
+func (c *collector) add(changeID string, version workflow.Version, ref WorkflowRef) {
+	if _, ok := c.usage[changeID]; !ok {
+		c.usage[changeID] = map[workflow.Version]*VersionUsage{}
+	}
+	u, ok := c.usage[changeID][version]
+	if !ok {
+		u = &VersionUsage{Version: version}
+		c.usage[changeID][version] = u
+	}
+	u.OpenWorkflows++
+	if len(u.Examples) < c.reporter.examples {
+		u.Examples = append(u.Examples, ref)
+	}
+}
+
+func (c *collector) report() *Report {
+	report := &Report{ScannedWorkflows: c.scanned, Truncated: c.truncated}
+
+	changes := map[string]*ChangeReport{}
+	if c.reporter.registry != nil {
+		for _, change := range c.reporter.registry.Changes() {
+			changes[change.ID] = &ChangeReport{ChangeID: change.ID, Declared: true, MinSupported: change.MinSupported, Max: change.Max}
+		}
+	}
+	for changeID, usage := range c.usage {
+		cr, ok := changes[changeID]
+		if !ok {
+			cr = &ChangeReport{ChangeID: changeID, MinSupported: workflow.DefaultVersion, Max: workflow.DefaultVersion}
+			changes[changeID] = cr
+		}
+		for _, u := range usage {
+			cr.Versions = append(cr.Versions, *u)
+			if !cr.Declared && u.Version > cr.Max {
+				cr.Max = u.Version
+			}
+		}
+		sort.Slice(cr.Versions, func(i, j int) bool { return cr.Versions[i].Version < cr.Versions[j].Version })
+	}
+
+	for _, cr := range changes {
+		report.Changes = append(report.Changes, *cr)
+	}
+	sort.Slice(report.Changes, func(i, j int) bool { return report.Changes[i].ChangeID < report.Changes[j].ChangeID })
+	return report
+}
@@ -12,9 +12,9 @@ import (
 	"go.uber.org/cadence/client"
 	"go.uber.org/cadence/worker"
 	"go.uber.org/yarpc"
-	"go.uber.org/yarpc/transport/tchannel"
 	"go.uber.org/zap"
 	"log/slog"
+	"slices"
 	"sync"
 )
 
@@ -26,7 +26,8 @@ const (
 type cadenceWorker struct {
 	gox.CrossFunction
 	workerGroup *WorkerGroup
-	logger      *zap.Logger
+	logger      func(taskList string) *zap.Logger
+	slogger     *slog.Logger
 
 	dispatcher           *yarpc.Dispatcher
 	createDispatcherOnce *sync.Once
@@ -34,7 +35,27 @@ type cadenceWorker struct {
 	cadenceDomainClient  client.DomainClient
 	cadenceClient        client.Client
 
+	// mu guards the workers, shadowers and paused task lists - they change at runtime with AddWorker, RemoveWorker,
+	// PauseWorker and ResumeWorker
+	mu             sync.RWMutex
 	cadenceWorkers map[string]worker.Worker
+	shadowers      map[string]*shadower
+	paused         map[string]bool
+
+	// interceptors gives the worker interceptors to use for a task list
+	interceptors func(taskList string) WorkerInterceptors
+
+	// clientOnly if true will not start the pollers and shadowers
+	clientOnly bool
+
+	// buildID is the binary checksum of the workers - the worker metrics are tagged with it
+	buildID string
+
+	// cluster is the name of the cluster the worker group is connected to (empty if clusters are not configured)
+	cluster string
+
+	// client is the retry policy and circuit breaker of the Api calls
+	client *ClientConfig
 
 	tallyScope tally.Scope
 }
@@ -43,11 +64,12 @@ type cadenceWorker struct {
 func (w *cadenceWorker) Start(ctx context.Context) error {
 	var err error
 
-	// See if w e can get the metric scope
-	if pm, ok := w.CrossFunction.Metric().(*prometheus.PrometheusMetrics); ok {
-		w.tallyScope = stats.TallyScopeWrapper{Scope: pm.Scope}
-	} else {
-		w.tallyScope = tally.NoopScope
+	w.tallyScope = metricScope(w.CrossFunction)
+	if len(w.buildID) > 0 {
+		w.tallyScope = w.tallyScope.Tagged(map[string]string{"build_id": w.buildID})
+	}
+	if len(w.cluster) > 0 {
+		w.tallyScope = w.tallyScope.Tagged(map[string]string{"cluster": w.cluster})
 	}
 
 	if w.cadenceClient, err = w.buildCadenceClient(); err != nil {
@@ -61,32 +83,38 @@ func (w *cadenceWorker) Start(ctx context.Context) error {
 	if domainInfo, err := w.cadenceDomainClient.Describe(context.Background(), w.workerGroup.Domain); err != nil {
 		return errors.Wrap(err, "failed to describe domain (check if it exists) - workerGroup=%s, domain=%s", w.workerGroup.Name, w.workerGroup.Domain)
 	} else {
-		slog.Info("Cadence domain info", slog.String("domain", w.workerGroup.Domain), slog.Any("domainInfo", domainInfo))
+		w.slogger.Info("Cadence domain info", slog.String("domain", w.workerGroup.Domain), slog.Any("domainInfo", domainInfo))
 	}
 
+	// Paused task lists are set before start when the worker group moves to another cluster
+	w.mu.Lock()
 	w.cadenceWorkers = make(map[string]worker.Worker)
+	w.shadowers = make(map[string]*shadower)
+	if w.paused == nil {
+		w.paused = make(map[string]bool)
+	}
+	w.mu.Unlock()
+
+	// The schedule launcher must be registered before the workers start
+	if len(w.workerGroup.Schedules) > 0 && !w.clientOnly {
+		registerScheduleLauncher()
+	}
 
 	// It's time to start the workers for each task list
 	for _, taskListWorker := range w.workerGroup.Workers {
-		cw := worker.New(
-			w.cadenceServiceClient,
-			w.workerGroup.Domain,
-			taskListWorker.TaskList,
-			worker.Options{
-				Tracer:       opentracing.GlobalTracer(),
-				MetricsScope: w.tallyScope,
-				Logger:       w.logger.Named("cadence-worker-" + taskListWorker.TaskList),
-			},
-		)
-
-		// Keep the worker reference - used in stopping the worker
-		w.cadenceWorkers[taskListWorker.TaskList] = cw
-
-		if err := cw.Start(); err != nil {
-			return errors.Wrap(err, "failed to start worker for taskList=%s", taskListWorker.TaskList)
+		if taskListWorker.Disabled {
+			w.slogger.Warn("cadence worker is disabled", slog.String("taskList", taskListWorker.TaskList))
+			continue
+		}
+		if err := w.startTaskListWorker(taskListWorker); err != nil {
+			return err
 		}
 	}
 
+	if len(w.workerGroup.Schedules) > 0 && !w.clientOnly {
+		w.reconcileSchedules(ctx)
+	}
+
 	return nil
 }
 
@@ -98,14 +126,182 @@ func (w *cadenceWorker) Shutdown(ctx context.Context, doneCh chan error) error {
 		close(doneCh)
 	}()
 
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for taskList, s := range w.shadowers {
+		s.Stop()
+		w.slogger.Info("cadence shadow worker stopped...", slog.String("taskList", taskList))
+	}
+
 	for taskList, cadenceWorkerObj := range w.cadenceWorkers {
+		if w.paused[taskList] {
+			continue
+		}
 		cadenceWorkerObj.Stop()
-		slog.Info("cadence worker stopped...", slog.String("taskList", taskList))
+		w.slogger.Info("cadence worker stopped...", slog.String("taskList", taskList))
+	}
+
+	return nil
+}
+
+// startTaskListWorker creates the worker for the task list and starts its pollers (and shadower). The task list is
+// added to routing only after the start succeeds, so a failed start can be retried.
+func (w *cadenceWorker) startTaskListWorker(taskListWorker *Worker) error {
+	cw := w.newTaskListWorker(taskListWorker)
+	if !w.clientOnly {
+		if err := w.startPollers(cw, taskListWorker); err != nil {
+			return err
+		}
+	}
+
+	// Keep the worker reference - used in routing and stopping the worker
+	w.mu.Lock()
+	w.cadenceWorkers[taskListWorker.TaskList] = cw
+	w.mu.Unlock()
+	return nil
+}
+
+// startPollers starts the shadower and the pollers of the task list worker - the shadower is stopped if the pollers
+// fail to start
+func (w *cadenceWorker) startPollers(cw worker.Worker, taskListWorker *Worker) error {
+	// Shadow worker replays the workflows from the domain - it runs along with (or instead of) the pollers
+	wi := w.interceptors(taskListWorker.TaskList)
+	shadow := taskListWorker.shadowConfig(w.workerGroup)
+	var s *shadower
+	if shadow != nil && shadow.Enabled {
+		s = newShadower(w, taskListWorker.TaskList, *shadow, wi)
+		s.Start()
+	}
+	if shadow.shadowOnly() {
+		w.slogger.Info("cadence worker in shadow only mode - pollers not started", slog.String("taskList", taskListWorker.TaskList))
+	} else if w.isPaused(taskListWorker.TaskList) {
+		w.slogger.Info("cadence worker is paused - pollers not started", slog.String("taskList", taskListWorker.TaskList))
+	} else if err := cw.Start(); err != nil {
+		if s != nil {
+			s.Stop()
+		}
+		return errors.Wrap(err, "failed to start worker for taskList=%s", taskListWorker.TaskList)
+	}
+
+	if s != nil {
+		w.mu.Lock()
+		w.shadowers[taskListWorker.TaskList] = s
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// isPaused returns true if the pollers of the task list are paused
+func (w *cadenceWorker) isPaused(taskList string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.paused[taskList]
+}
+
+// newTaskListWorker creates (but does not start) the cadence worker for the task list
+func (w *cadenceWorker) newTaskListWorker(taskListWorker *Worker) worker.Worker {
+	wi := w.interceptors(taskListWorker.TaskList)
+	return worker.New(
+		w.cadenceServiceClient,
+		w.workerGroup.Domain,
+		taskListWorker.TaskList,
+		worker.Options{
+			Tracer:       opentracing.GlobalTracer(),
+			MetricsScope: w.tallyScope,
+			Logger:       w.logger(taskListWorker.TaskList),
+
+			WorkflowInterceptorChainFactories: wi.Workflow,
+			BackgroundActivityContext:         withActivityInterceptors(context.Background(), wi.Activity),
+
+			WorkerActivitiesPerSecond:              taskListWorker.ActivitiesPerSecond,
+			TaskListActivitiesPerSecond:            taskListWorker.TaskListActivitiesPerSecond,
+			WorkerDecisionTasksPerSecond:           taskListWorker.DecisionsPerSecond,
+			MaxConcurrentActivityExecutionSize:     taskListWorker.MaxConcurrentActivities,
+			MaxConcurrentDecisionTaskExecutionSize: taskListWorker.MaxConcurrentDecisions,
+			MaxConcurrentActivityTaskPollers:       taskListWorker.ActivityPollers,
+			MaxConcurrentDecisionTaskPollers:       taskListWorker.DecisionPollers,
+		},
+	)
+}
+
+// workers returns the task list workers of the group - the returned slice is never modified
+func (w *cadenceWorker) workers() []*Worker {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.workerGroup.Workers
+}
+
+// retuneTaskListWorker restarts the task list worker with the new tuning. A paused (or shadow only) worker gets the
+// new tuning when it is started. If the worker with the new tuning fails to start, the worker is started again with
+// the old tuning; if that also fails the task list is marked paused (it can be started with ResumeWorker).
+func (w *cadenceWorker) retuneTaskListWorker(taskListWorker *Worker) error {
+	taskList := taskListWorker.TaskList
+	old := w.workerConfig(taskList)
+	w.mu.Lock()
+	w.workerGroup.Workers = replaceWorker(w.workerGroup.Workers, taskListWorker)
+	cw, paused := w.cadenceWorkers[taskList], w.paused[taskList]
+	w.mu.Unlock()
+	if w.clientOnly || paused || taskListWorker.shadowConfig(w.workerGroup).shadowOnly() {
+		return nil
 	}
 
+	cw.Stop()
+	cw = w.newTaskListWorker(taskListWorker)
+	if err := cw.Start(); err != nil {
+		err = errors.Wrap(err, "failed to start worker for taskList=%s", taskList)
+
+		// The stopped worker must not stay in the group - a cadence worker panics if it is stopped twice
+		rollback := w.newTaskListWorker(old)
+		rollbackErr := rollback.Start()
+		w.mu.Lock()
+		w.workerGroup.Workers = replaceWorker(w.workerGroup.Workers, old)
+		w.cadenceWorkers[taskList] = rollback
+		if rollbackErr != nil {
+			w.paused[taskList] = true
+		}
+		w.mu.Unlock()
+		if rollbackErr != nil {
+			w.slogger.Error("failed to start cadence worker with old tuning - worker is paused",
+				slog.String("taskList", taskList), slog.String("error", rollbackErr.Error()))
+		} else {
+			w.slogger.Warn("cadence worker restarted with old tuning", slog.String("taskList", taskList), slog.String("error", err.Error()))
+		}
+		return err
+	}
+	w.mu.Lock()
+	w.cadenceWorkers[taskList] = cw
+	w.mu.Unlock()
+	w.slogger.Info("cadence worker restarted with new tuning", slog.String("taskList", taskList))
 	return nil
 }
 
+// replaceWorker returns a copy of the workers with the worker of the same task list replaced (or added)
+func replaceWorker(workers []*Worker, taskListWorker *Worker) []*Worker {
+	workers = slices.DeleteFunc(slices.Clone(workers), func(w *Worker) bool { return w.TaskList == taskListWorker.TaskList })
+	return append(workers, taskListWorker)
+}
+
+// workerConfig returns the config of the task list worker
+func (w *cadenceWorker) workerConfig(taskList string) *Worker {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, taskListWorker := range w.workerGroup.Workers {
+		if taskListWorker.TaskList == taskList {
+			return taskListWorker
+		}
+	}
+	return &Worker{TaskList: taskList}
+}
+
+// hasTaskList returns true if the task list is served by this worker group
+func (w *cadenceWorker) hasTaskList(taskList string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.cadenceWorkers[taskList]
+	return ok
+}
+
 func (w *cadenceWorker) buildCadenceClient() (client.Client, error) {
 	if service, err := w.buildCadenceServiceClient(); err == nil {
 		return client.NewClient(service, w.workerGroup.Domain, &client.Options{
@@ -142,23 +338,13 @@ func (w *cadenceWorker) buildAndStartDispatcher() (dispatcher *yarpc.Dispatcher,
 
 // implBuildAndStartDispatcher creates a new dispatcher and also starts it
 func (w *cadenceWorker) implBuildAndStartDispatcher() (*yarpc.Dispatcher, error) {
-	serviceName := cadenceClientName + "_" + w.workerGroup.Name
-
-	channelTransport, err := tchannel.NewChannelTransport(tchannel.ServiceName(serviceName))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create channel transport for cadenceClient=%s", serviceName)
-	}
-
-	dispatcher := yarpc.NewDispatcher(yarpc.Config{
-		Name: serviceName,
-		Outbounds: yarpc.Outbounds{
-			cadenceService: {Unary: channelTransport.NewSingleOutbound(w.workerGroup.HostPort)},
-		},
-	})
+	return startDispatcher(cadenceClientName+"_"+w.workerGroup.Name, w.workerGroup.HostPort, w.workerGroup.HostResolveInterval, w.slogger)
+}
 
-	if err = dispatcher.Start(); err != nil {
-		return nil, errors.Wrap(err, "field to start dispatcher for cadenceClient=%s", serviceName)
+// metricScope returns the tally scope of the prometheus metrics (noop scope for other metrics)
+func metricScope(cf gox.CrossFunction) tally.Scope {
+	if pm, ok := cf.Metric().(*prometheus.PrometheusMetrics); ok {
+		return stats.TallyScopeWrapper{Scope: pm.Scope}
 	}
-
-	return dispatcher, nil
+	return tally.NoopScope
 }
@@ -0,0 +1,167 @@
+package cadence
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"log/slog"
+	"slices"
+)
+
+func (wrapper *cadenceWrapperImpl) AddWorker(ctx context.Context, workerGroup string, taskListWorker Worker) error {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+	if err := wrapper.checkNotClosed(); err != nil {
+		return err
+	}
+	return wrapper.addWorker(workerGroup, taskListWorker)
+}
+
+func (wrapper *cadenceWrapperImpl) addWorker(workerGroup string, taskListWorker Worker) error {
+	if err := taskListWorker.Validate(); err != nil {
+		return errors.Wrap(err, "bad worker config for task list = %s", taskListWorker.TaskList)
+	}
+	var group *cadenceWorker
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskListWorker.TaskList) {
+			return errors.New("task list already exists in worker group %s: %s", cadenceWorkerObj.workerGroup.Name, taskListWorker.TaskList)
+		}
+		if cadenceWorkerObj.workerGroup.Name == workerGroup {
+			group = cadenceWorkerObj
+		}
+	}
+	if group == nil {
+		return errors.New("worker group not found (or disabled): %s", workerGroup)
+	}
+
+	if err := group.startTaskListWorker(&taskListWorker); err != nil {
+		return err
+	}
+	group.mu.Lock()
+	group.workerGroup.Workers = replaceWorker(group.workerGroup.Workers, &taskListWorker)
+	group.mu.Unlock()
+	group.slogger.Info("cadence worker added", slog.String("taskList", taskListWorker.TaskList))
+	return nil
+}
+
+func (wrapper *cadenceWrapperImpl) RemoveWorker(ctx context.Context, taskList string) error {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+	if err := wrapper.checkNotClosed(); err != nil {
+		return err
+	}
+	return wrapper.removeWorker(taskList)
+}
+
+func (wrapper *cadenceWrapperImpl) removeWorker(taskList string) error {
+	group, err := wrapper.workerGroupForTaskList(taskList)
+	if err != nil {
+		return err
+	}
+
+	// Remove the task list from routing first, then stop the pollers (stop waits for the running tasks)
+	group.mu.Lock()
+	cw, s, paused := group.cadenceWorkers[taskList], group.shadowers[taskList], group.paused[taskList]
+	delete(group.cadenceWorkers, taskList)
+	delete(group.shadowers, taskList)
+	delete(group.paused, taskList)
+	group.workerGroup.Workers = slices.DeleteFunc(slices.Clone(group.workerGroup.Workers), func(w *Worker) bool { return w.TaskList == taskList })
+	group.mu.Unlock()
+
+	if s != nil {
+		s.Stop()
+	}
+	if !paused {
+		cw.Stop()
+	}
+	group.slogger.Info("cadence worker removed", slog.String("taskList", taskList))
+	return nil
+}
+
+func (wrapper *cadenceWrapperImpl) PauseWorker(ctx context.Context, taskList string) error {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+	if err := wrapper.checkNotClosed(); err != nil {
+		return err
+	}
+
+	group, err := wrapper.pollingWorkerGroup(taskList)
+	if err != nil {
+		return err
+	}
+
+	group.mu.Lock()
+	if group.paused[taskList] {
+		group.mu.Unlock()
+		return nil
+	}
+	group.paused[taskList] = true
+	cw := group.cadenceWorkers[taskList]
+	group.mu.Unlock()
+
+	// Workflows can still be started and signalled - their tasks wait in the task list until the worker is resumed
+	cw.Stop()
+	group.slogger.Info("cadence worker paused", slog.String("taskList", taskList))
+	return nil
+}
+
+func (wrapper *cadenceWrapperImpl) ResumeWorker(ctx context.Context, taskList string) error {
+	wrapper.controlMu.Lock()
+	defer wrapper.controlMu.Unlock()
+	if err := wrapper.checkNotClosed(); err != nil {
+		return err
+	}
+
+	group, err := wrapper.pollingWorkerGroup(taskList)
+	if err != nil {
+		return err
+	}
+
+	group.mu.RLock()
+	paused := group.paused[taskList]
+	group.mu.RUnlock()
+	if !paused {
+		return nil
+	}
+
+	// A stopped cadence worker cannot be started again - a new one is created
+	cw := group.newTaskListWorker(group.workerConfig(taskList))
+	if err := cw.Start(); err != nil {
+		return errors.Wrap(err, "failed to start worker for taskList=%s", taskList)
+	}
+	group.mu.Lock()
+	group.cadenceWorkers[taskList] = cw
+	delete(group.paused, taskList)
+	group.mu.Unlock()
+	group.slogger.Info("cadence worker resumed", slog.String("taskList", taskList))
+	return nil
+}
+
+// workerGroupForTaskList returns the worker group which serves the task list
+func (wrapper *cadenceWrapperImpl) workerGroupForTaskList(taskList string) (*cadenceWorker, error) {
+	for _, cadenceWorkerObj := range wrapper.groups() {
+		if cadenceWorkerObj.hasTaskList(taskList) {
+			return cadenceWorkerObj, nil
+		}
+	}
+	return nil, errors.New("task list not registered in application config: %s", taskList)
+}
+
+// pollingWorkerGroup returns the worker group of the task list if the task list runs pollers
+func (wrapper *cadenceWrapperImpl) pollingWorkerGroup(taskList string) (*cadenceWorker, error) {
+	if wrapper.clientOnly {
+		return nil, errors.New("cadence client is in client only mode - no worker to pause or resume: %s", taskList)
+	}
+	group, err := wrapper.workerGroupForTaskList(taskList)
+	if err != nil {
+		return nil, err
+	}
+
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	for _, w := range group.workerGroup.Workers {
+		if w.TaskList == taskList && w.shadowConfig(group.workerGroup).shadowOnly() {
+			return nil, errors.New("task list is in shadow only mode - no worker to pause or resume: %s", taskList)
+		}
+	}
+	return group, nil
+}